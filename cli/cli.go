@@ -0,0 +1,226 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+// Package cli implements the commands run from the provider binary itself, outside of Terraform,
+// e.g. `terraform-provider-smilecdr export`.
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+)
+
+type command struct {
+	summary string
+	run     func(args []string, stdout io.Writer) error
+}
+
+var commands = map[string]command{
+	"export":   {summary: "Write Terraform configuration and import blocks for the objects of a server", run: runExport},
+	"diff":     {summary: "Compare the modules, OpenID clients and identity providers of two servers or inventory files", run: runDiff},
+	"snapshot": {summary: "Save the modules, OpenID clients, identity providers and users of a server to an archive", run: runSnapshot},
+	"restore":  {summary: "Create the objects of a snapshot archive on an empty server", run: runRestore},
+	"script":   {summary: "Run a callback script locally against fixture inputs, without a server", run: runScript},
+}
+
+// IsCommand reports whether the first argument of the binary names a command, rather than
+// the binary being started by Terraform as a plugin.
+func IsCommand(name string) bool {
+	_, ok := commands[name]
+	return ok || name == "help" || name == "-h" || name == "--help"
+}
+
+// Run executes the command named by args[0] and returns the exit code of the process.
+func Run(args []string) int {
+	// The client logs requests and responses to stdout, keep them away from the command output.
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	defer func() { os.Stdout = stdout }()
+
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(os.Stderr)
+		return 0
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		usage(os.Stderr)
+		return 2
+	}
+
+	if err := cmd.run(args[1:], stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: terraform-provider-smilecdr <command> [options]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-10s %s\n", name, commands[name].summary)
+	}
+}
+
+// connection holds the settings of a Smile CDR connection, read like the provider configuration: command
+// line flags take precedence over the SMILECDR_* environment variables, which take precedence over the
+// selected profile of the profiles file.
+type connection struct {
+	profile       string
+	profilesFile  string
+	baseUrl       string
+	authMethod    string
+	username      string
+	password      string
+	token         string
+	caCertFile    string
+	skipTLSVerify optionalBool
+}
+
+func (c *connection) register(fs *flag.FlagSet, prefix string) {
+	fs.StringVar(&c.profile, prefix+"profile", os.Getenv("SMILECDR_PROFILE"), "Profile of the profiles file to read the connection settings from, by default the default profile if there is one (env SMILECDR_PROFILE)")
+	fs.StringVar(&c.profilesFile, prefix+"profiles-file", os.Getenv("SMILECDR_PROFILES_FILE"), "Profiles file, by default ~/.smilecdr/config (env SMILECDR_PROFILES_FILE)")
+	fs.StringVar(&c.baseUrl, prefix+"base-url", os.Getenv("SMILECDR_BASE_URL"), "Admin JSON API base URL, by default from the profile or http://localhost:9000 (env SMILECDR_BASE_URL)")
+	fs.StringVar(&c.authMethod, prefix+"auth-method", os.Getenv("SMILECDR_AUTH_METHOD"), "How to authenticate: basic (default) or bearer (env SMILECDR_AUTH_METHOD)")
+	fs.StringVar(&c.username, prefix+"username", os.Getenv("SMILECDR_USERNAME"), "Admin JSON API username (env SMILECDR_USERNAME)")
+	fs.StringVar(&c.password, prefix+"password", os.Getenv("SMILECDR_PASSWORD"), "Admin JSON API password (env SMILECDR_PASSWORD)")
+	fs.StringVar(&c.token, prefix+"token", os.Getenv("SMILECDR_TOKEN"), "Bearer token of the bearer auth method (env SMILECDR_TOKEN)")
+	fs.StringVar(&c.caCertFile, prefix+"ca-cert-file", os.Getenv("SMILECDR_CA_CERT_FILE"), "PEM file of certificate authorities to trust, in addition to the system ones (env SMILECDR_CA_CERT_FILE)")
+	fs.Var(&c.skipTLSVerify, prefix+"tls-insecure-skip-verify", "Do not verify the certificate of the server, by default from the profile. Only for test servers")
+}
+
+// client connects with the settings of the connection, and records the base URL it connects to.
+func (c *connection) client(ctx context.Context) (*smilecdr.Client, error) {
+	profile, err := c.selectProfile()
+	if err != nil {
+		return nil, err
+	}
+
+	config := smilecdr.ClientConfig{
+		BaseUrl:               firstNonEmpty(c.baseUrl, profile.BaseUrl, "http://localhost:9000"),
+		AuthMethod:            firstNonEmpty(c.authMethod, profile.AuthMethod, smilecdr.AuthMethodBasic),
+		Username:              firstNonEmpty(c.username, profile.Username),
+		Password:              firstNonEmpty(c.password, profile.Password),
+		Token:                 firstNonEmpty(c.token, profile.Token),
+		CACertFile:            firstNonEmpty(c.caCertFile, profile.CACertFile),
+		TLSInsecureSkipVerify: profile.TLSInsecureSkipVerify,
+	}
+	if c.skipTLSVerify.set {
+		config.TLSInsecureSkipVerify = c.skipTLSVerify.value
+	}
+	if config.AuthMethod == smilecdr.AuthMethodBearer && config.Token == "" {
+		return nil, fmt.Errorf("missing connection settings: the bearer auth method requires a token")
+	}
+	if config.AuthMethod != smilecdr.AuthMethodBearer && (config.Username == "" || config.Password == "") {
+		return nil, fmt.Errorf("missing connection settings: a username and password are required")
+	}
+
+	c.baseUrl = config.BaseUrl
+	return smilecdr.NewClientFromConfig(ctx, config)
+}
+
+// selectProfile returns the selected profile, or else the default profile. A missing profiles file is
+// only an error when a profile is selected.
+func (c *connection) selectProfile() (smilecdr.Profile, error) {
+	path := firstNonEmpty(c.profilesFile, smilecdr.DefaultProfilesPath())
+	profiles, err := smilecdr.ReadProfiles(path)
+	if c.profile == "" && errors.Is(err, fs.ErrNotExist) {
+		return smilecdr.Profile{}, nil
+	}
+	if err != nil {
+		return smilecdr.Profile{}, fmt.Errorf("reading the profiles file: %w", err)
+	}
+
+	if c.profile == "" {
+		return profiles[smilecdr.DefaultProfileName], nil
+	}
+	profile, ok := profiles[c.profile]
+	if !ok {
+		names := make([]string, 0, len(profiles))
+		for name := range profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return profile, fmt.Errorf("profile %q not found in %s, the profiles are: %s", c.profile, path, strings.Join(names, ", "))
+	}
+	return profile, nil
+}
+
+// optionalBool is a boolean flag that tells an explicit false apart from a flag left out, so that
+// -tls-insecure-skip-verify=false overrides a profile skipping the verification.
+type optionalBool struct {
+	value, set bool
+}
+
+func (b *optionalBool) String() string {
+	return strconv.FormatBool(b.value)
+}
+
+func (b *optionalBool) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	b.value, b.set = v, true
+	return nil
+}
+
+func (b *optionalBool) IsBoolFlag() bool {
+	return true
+}
+
+// nodeIdsUsage completes the description of the -node-id flag of the commands reading a server.
+const nodeIdsUsage = ": a node ID, a comma-separated list of node IDs, or all for every node of the cluster"
+
+// selectNodeIds returns the nodes named by a -node-id flag, listing the nodes of the cluster for "all".
+func selectNodeIds(ctx context.Context, client *smilecdr.Client, value string) ([]string, error) {
+	var nodeIds []string
+	if value != "all" {
+		for _, nodeId := range strings.Split(value, ",") {
+			if nodeId = strings.TrimSpace(nodeId); nodeId != "" {
+				nodeIds = append(nodeIds, nodeId)
+			}
+		}
+		if len(nodeIds) == 0 {
+			return nil, fmt.Errorf("missing -node-id%s", nodeIdsUsage)
+		}
+		return nodeIds, nil
+	}
+
+	nodes, err := client.GetNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing the nodes of the cluster: %w", err)
+	}
+	for _, node := range nodes {
+		nodeIds = append(nodeIds, node.NodeId)
+	}
+	sort.Strings(nodeIds)
+	return nodeIds, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
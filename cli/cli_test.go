@@ -0,0 +1,94 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConnectionProfiles(t *testing.T) {
+	server := newTestServer()
+	baseUrl := server.Start()
+	defer server.Close()
+	tlsServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer tlsServer.Close()
+
+	for _, key := range []string{"SMILECDR_PROFILE", "SMILECDR_PROFILES_FILE", "SMILECDR_BASE_URL", "SMILECDR_AUTH_METHOD", "SMILECDR_USERNAME", "SMILECDR_PASSWORD", "SMILECDR_TOKEN", "SMILECDR_CA_CERT_FILE"} {
+		t.Setenv(key, "")
+	}
+	profiles := filepath.Join(t.TempDir(), "config")
+	content := fmt.Sprintf(`[dev]
+base_url = %s
+username = admin
+password = password
+
+[service]
+base_url = %s
+auth_method = bearer
+
+[insecure]
+base_url = %s
+username = admin
+password = password
+tls_insecure_skip_verify = true
+`, baseUrl, baseUrl, tlsServer.URL)
+	if err := os.WriteFile(profiles, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout bytes.Buffer
+	archive := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := runSnapshot([]string{"-profiles-file", profiles, "-profile", "dev", "-out", archive}, &stdout); err != nil {
+		t.Fatalf("snapshot with a profile failed: %s", err)
+	}
+	if len(server.Requests) == 0 {
+		t.Error("the profile did not connect to its base URL")
+	}
+
+	err := runSnapshot([]string{"-profiles-file", profiles, "-profile", "prod", "-out", archive}, &stdout)
+	if err == nil || !strings.Contains(err.Error(), `profile "prod" not found`) || !strings.Contains(err.Error(), "dev, insecure, service") {
+		t.Errorf("expected an unknown profile to fail, got %v", err)
+	}
+	err = runSnapshot([]string{"-profiles-file", profiles, "-profile", "service", "-out", archive}, &stdout)
+	if err == nil || !strings.Contains(err.Error(), "requires a token") {
+		t.Errorf("expected the bearer auth method to require a token, got %v", err)
+	}
+	if err := runSnapshot([]string{"-profiles-file", profiles, "-profile", "service", "-token", "service-token", "-out", archive}, &stdout); err != nil {
+		t.Errorf("snapshot with a bearer token failed: %s", err)
+	}
+
+	for _, test := range []struct {
+		args     []string
+		verified bool
+	}{
+		{[]string{"-profile", "insecure"}, false},
+		{[]string{"-profile", "insecure", "-tls-insecure-skip-verify=false"}, true},
+		{[]string{"-profile", "dev", "-base-url", tlsServer.URL}, true},
+		{[]string{"-profile", "dev", "-base-url", tlsServer.URL, "-tls-insecure-skip-verify"}, false},
+	} {
+		var conn connection
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		conn.register(fs, "")
+		if err := fs.Parse(append([]string{"-profiles-file", profiles}, test.args...)); err != nil {
+			t.Fatal(err)
+		}
+		client, err := conn.client(context.Background())
+		if err != nil {
+			t.Fatalf("with %v: %s", test.args, err)
+		}
+		_, err = client.GetNodes(context.Background())
+		if verified := err != nil && strings.Contains(err.Error(), "certificate"); verified != test.verified {
+			t.Errorf("with %v, expected the certificate to be verified: %t, got %v", test.args, test.verified, err)
+		}
+	}
+}
@@ -0,0 +1,501 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+)
+
+// Change is a single difference between the left and right inventories.
+type Change struct {
+	Node   string `json:"node,omitempty"`
+	Kind   string `json:"kind"`
+	Object string `json:"object"`
+	Field  string `json:"field,omitempty"`
+	Action string `json:"action"`
+	Left   string `json:"left,omitempty"`
+	Right  string `json:"right,omitempty"`
+}
+
+const (
+	actionAdded   = "added"
+	actionRemoved = "removed"
+	actionChanged = "changed"
+)
+
+// The value printed in place of credentials, see smilecdr.IsCredentialName, which are compared without
+// ever being printed.
+const maskedValue = "(masked)"
+
+// Fields assigned by each server, which always differ between environments.
+var ignoredFields = map[string]bool{
+	"pid":        true,
+	"archivedAt": true,
+}
+
+type side struct {
+	conn connection
+	file string
+	out  string
+}
+
+func (s *side) register(fs *flag.FlagSet, name string) {
+	s.conn.register(fs, name+"-")
+	fs.StringVar(&s.file, name+"-file", "", "Inventory JSON file to read instead of connecting to the "+name+" server")
+	fs.StringVar(&s.out, name+"-out", "", "Save the inventory read from the "+name+" server to this file, without its credentials, for later offline comparison")
+}
+
+// inventory reads the nodes selected by -node-id from the server, or every node saved in the file.
+func (s *side) inventory(ctx context.Context, nodeIds string) (smilecdr.Inventory, error) {
+	if s.file != "" {
+		return smilecdr.ReadInventoryFile(s.file)
+	}
+
+	client, err := s.conn.client(ctx)
+	if err != nil {
+		return smilecdr.Inventory{}, err
+	}
+	selected, err := selectNodeIds(ctx, client, nodeIds)
+	if err != nil {
+		return smilecdr.Inventory{}, err
+	}
+	var nodes []smilecdr.Inventory
+	for _, nodeId := range selected {
+		node, err := client.GetInventory(ctx, nodeId)
+		if err != nil {
+			return smilecdr.Inventory{}, fmt.Errorf("node %s: %w", nodeId, err)
+		}
+		nodes = append(nodes, node)
+	}
+	inventory := smilecdr.ClusterInventory(nodes)
+	if s.out != "" {
+		// Like a snapshot, a saved inventory never holds credentials.
+		saved := inventory
+		saved.RemoveCredentials()
+		if err := smilecdr.WriteInventoryFile(s.out, saved); err != nil {
+			return inventory, err
+		}
+	}
+	return inventory, nil
+}
+
+func runDiff(args []string, stdout io.Writer) error {
+	var left, right side
+	var format, nodeIds string
+
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	left.register(fs, "left")
+	right.register(fs, "right")
+	fs.StringVar(&format, "format", "text", "Output format: text, json or markdown")
+	fs.StringVar(&nodeIds, "node-id", "Master", "Nodes to read from the servers"+nodeIdsUsage+". An inventory file is compared with every node it holds")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	render, ok := diffFormats[format]
+	if !ok {
+		return fmt.Errorf("unknown format %q, expected text, json or markdown", format)
+	}
+
+	ctx := context.Background()
+	leftInventory, err := left.inventory(ctx, nodeIds)
+	if err != nil {
+		return fmt.Errorf("left: %w", err)
+	}
+	rightInventory, err := right.inventory(ctx, nodeIds)
+	if err != nil {
+		return fmt.Errorf("right: %w", err)
+	}
+
+	// Saved inventories hold no credentials, so these are only compared between two servers.
+	if left.file != "" || right.file != "" {
+		leftInventory.RemoveCredentials()
+		rightInventory.RemoveCredentials()
+	}
+
+	return render(stdout, diffInventories(leftInventory, rightInventory))
+}
+
+// diffInventories compares the nodes of two inventories. Two single nodes are compared whatever their
+// ids, so that a node can be compared with another one. Otherwise nodes are paired by node id, and the
+// changes are reported with their node.
+func diffInventories(left smilecdr.Inventory, right smilecdr.Inventory) []Change {
+	leftNodes, rightNodes := left.NodeInventories(), right.NodeInventories()
+	if len(leftNodes) == 1 && len(rightNodes) == 1 {
+		return diffNode(leftNodes[0], rightNodes[0])
+	}
+
+	var changes []Change
+	leftById, rightById := map[string]smilecdr.Inventory{}, map[string]smilecdr.Inventory{}
+	for _, node := range leftNodes {
+		leftById[node.NodeId] = node
+	}
+	for _, node := range rightNodes {
+		rightById[node.NodeId] = node
+	}
+	for _, id := range unionKeys(leftById, rightById) {
+		l, inLeft := leftById[id]
+		r, inRight := rightById[id]
+		switch {
+		case !inRight:
+			changes = append(changes, Change{Kind: "node", Object: id, Action: actionRemoved})
+		case !inLeft:
+			changes = append(changes, Change{Kind: "node", Object: id, Action: actionAdded})
+		default:
+			for _, change := range diffNode(l, r) {
+				change.Node = id
+				changes = append(changes, change)
+			}
+		}
+	}
+	return changes
+}
+
+// diffNode compares modules by module id, OpenID clients by module and client id, and identity
+// providers by module and name, as the issuer usually differs between environments.
+func diffNode(left smilecdr.Inventory, right smilecdr.Inventory) []Change {
+	var changes []Change
+
+	leftModules, rightModules := map[string]smilecdr.ModuleConfig{}, map[string]smilecdr.ModuleConfig{}
+	for _, m := range left.Modules {
+		leftModules[m.ModuleId] = m
+	}
+	for _, m := range right.Modules {
+		rightModules[m.ModuleId] = m
+	}
+	for _, id := range unionKeys(leftModules, rightModules) {
+		l, inLeft := leftModules[id]
+		r, inRight := rightModules[id]
+		switch {
+		case !inRight:
+			changes = append(changes, Change{Kind: "module", Object: id, Action: actionRemoved, Left: l.ModuleType})
+		case !inLeft:
+			changes = append(changes, Change{Kind: "module", Object: id, Action: actionAdded, Right: r.ModuleType})
+		default:
+			changes = append(changes, diffModule(id, l, r)...)
+		}
+	}
+
+	leftClients, rightClients := map[string]interface{}{}, map[string]interface{}{}
+	for _, c := range left.OpenIdClients {
+		leftClients[c.ModuleId+"/"+c.ClientId] = c
+	}
+	for _, c := range right.OpenIdClients {
+		rightClients[c.ModuleId+"/"+c.ClientId] = c
+	}
+	changes = append(changes, diffObjects("openid_client", leftClients, rightClients)...)
+
+	leftProviders, rightProviders := map[string]interface{}{}, map[string]interface{}{}
+	for _, p := range left.IdentityProviders {
+		leftProviders[p.ModuleId+"/"+identityProviderName(p)] = p
+	}
+	for _, p := range right.IdentityProviders {
+		rightProviders[p.ModuleId+"/"+identityProviderName(p)] = p
+	}
+	changes = append(changes, diffObjects("identity_provider", leftProviders, rightProviders)...)
+
+	return changes
+}
+
+func identityProviderName(p smilecdr.OpenIdIdentityProvider) string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.Issuer
+}
+
+func diffModule(id string, left smilecdr.ModuleConfig, right smilecdr.ModuleConfig) []Change {
+	var changes []Change
+
+	if left.ModuleType != right.ModuleType {
+		changes = append(changes, Change{Kind: "module", Object: id, Field: "moduleType", Action: actionChanged, Left: left.ModuleType, Right: right.ModuleType})
+	}
+
+	leftOptions, rightOptions := map[string]string{}, map[string]string{}
+	for _, o := range left.Options {
+		leftOptions[o.Key] = o.Value
+	}
+	for _, o := range right.Options {
+		rightOptions[o.Key] = o.Value
+	}
+	for _, key := range unionKeys(leftOptions, rightOptions) {
+		if change, ok := diffValue(leftOptions, rightOptions, key); ok {
+			change.Kind, change.Object, change.Field = "module", id, "options."+key
+			changes = append(changes, maskChange(key, change))
+		}
+	}
+
+	// Dependencies are compared by slot type: what matters is which module fills each slot.
+	leftDependencies, rightDependencies := map[string]string{}, map[string]string{}
+	for _, d := range left.Dependencies {
+		leftDependencies[d.Type] = d.ModuleId
+	}
+	for _, d := range right.Dependencies {
+		rightDependencies[d.Type] = d.ModuleId
+	}
+	for _, slot := range unionKeys(leftDependencies, rightDependencies) {
+		if change, ok := diffValue(leftDependencies, rightDependencies, slot); ok {
+			change.Kind, change.Object, change.Field = "module", id, "dependencies."+slot
+			changes = append(changes, change)
+		}
+	}
+
+	return changes
+}
+
+func diffObjects(kind string, left map[string]interface{}, right map[string]interface{}) []Change {
+	var changes []Change
+
+	for _, id := range unionKeys(left, right) {
+		l, inLeft := left[id]
+		r, inRight := right[id]
+		switch {
+		case !inRight:
+			changes = append(changes, Change{Kind: kind, Object: id, Action: actionRemoved})
+		case !inLeft:
+			changes = append(changes, Change{Kind: kind, Object: id, Action: actionAdded})
+		default:
+			leftFields, rightFields := comparableFields(l, false), comparableFields(r, false)
+			leftDisplay, rightDisplay := comparableFields(l, true), comparableFields(r, true)
+			for _, field := range unionKeys(leftFields, rightFields) {
+				if _, ok := diffValue(leftFields, rightFields, field); ok {
+					change, visible := diffValue(leftDisplay, rightDisplay, field)
+					if !visible {
+						// Only a masked credential differs
+						change = Change{Action: actionChanged, Left: leftDisplay[field], Right: rightDisplay[field]}
+					}
+					change.Kind, change.Object, change.Field = kind, id, field
+					changes = append(changes, maskChange(field, change))
+				}
+			}
+		}
+	}
+
+	return changes
+}
+
+func diffValue(left map[string]string, right map[string]string, key string) (Change, bool) {
+	l, inLeft := left[key]
+	r, inRight := right[key]
+	switch {
+	case inLeft && inRight && l == r:
+		return Change{}, false
+	case !inRight:
+		return Change{Action: actionRemoved, Left: l}, true
+	case !inLeft:
+		return Change{Action: actionAdded, Right: r}, true
+	}
+	return Change{Action: actionChanged, Left: l, Right: r}, true
+}
+
+// maskChange hides the values of a change to a credential. Two different secrets are still
+// reported as a change, as the comparison is made before masking.
+func maskChange(name string, change Change) Change {
+	if !smilecdr.IsCredentialName(name) || isStructured(change.Left) || isStructured(change.Right) {
+		return change
+	}
+	if change.Left != "" {
+		change.Left = maskedValue
+	}
+	if change.Right != "" {
+		change.Right = maskedValue
+	}
+	return change
+}
+
+func isStructured(value string) bool {
+	return strings.HasPrefix(value, "[") || strings.HasPrefix(value, "{")
+}
+
+// comparableFields flattens an API object into its JSON fields, with lists sorted so that their
+// order does not matter, and with the fields assigned by each server left out. Credentials nested
+// in a field are replaced by a digest for comparison, or masked for display.
+func comparableFields(object interface{}, display bool) map[string]string {
+	fields := map[string]string{}
+
+	content, _ := json.Marshal(object)
+	var values map[string]interface{}
+	json.Unmarshal(content, &values)
+
+	for name, value := range values {
+		// A field left empty compares like a field left out, whichever way the server reports it.
+		if ignoredFields[name] || isEmptyValue(value) {
+			continue
+		}
+		fields[name] = canonicalValue(value, display)
+	}
+	return fields
+}
+
+func isEmptyValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+func canonicalValue(value interface{}, display bool) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = canonicalValue(item, display)
+		}
+		sort.Strings(items)
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			if !ignoredFields[key] {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		parts := make([]string, len(keys))
+		for i, key := range keys {
+			item := canonicalValue(v[key], display)
+			if smilecdr.IsCredentialName(key) && display {
+				item = maskedValue
+			} else if smilecdr.IsCredentialName(key) {
+				item = digest(item)
+			}
+			parts[i] = key + "=" + item
+		}
+		return "{" + strings.Join(parts, " ") + "}"
+	}
+	content, _ := json.Marshal(value)
+	return string(content)
+}
+
+func digest(value string) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(value)))
+}
+
+func unionKeys(maps ...interface{}) []string {
+	seen := map[string]bool{}
+	for _, m := range maps {
+		for _, key := range reflect.ValueOf(m).MapKeys() {
+			seen[key.String()] = true
+		}
+	}
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var diffFormats = map[string]func(io.Writer, []Change) error{
+	"text":     renderDiffText,
+	"json":     renderDiffJSON,
+	"markdown": renderDiffMarkdown,
+}
+
+var actionSymbols = map[string]string{
+	actionAdded:   "+",
+	actionRemoved: "-",
+	actionChanged: "~",
+}
+
+func renderDiffText(w io.Writer, changes []Change) error {
+	if len(changes) == 0 {
+		_, err := fmt.Fprintln(w, "No differences.")
+		return err
+	}
+	for _, c := range changes {
+		target := c.Kind + " " + c.Object
+		if c.Node != "" {
+			target = "node " + c.Node + " " + target
+		}
+		if c.Field != "" {
+			target += " " + c.Field
+		}
+		var err error
+		switch c.Action {
+		case actionChanged:
+			_, err = fmt.Fprintf(w, "%s %s: %q -> %q\n", actionSymbols[c.Action], target, c.Left, c.Right)
+		case actionAdded:
+			_, err = fmt.Fprintf(w, "%s %s %s\n", actionSymbols[c.Action], target, quoteIfSet(c.Right))
+		default:
+			_, err = fmt.Fprintf(w, "%s %s %s\n", actionSymbols[c.Action], target, quoteIfSet(c.Left))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func quoteIfSet(value string) string {
+	if value == "" {
+		return ""
+	}
+	return fmt.Sprintf("%q", value)
+}
+
+func renderDiffJSON(w io.Writer, changes []Change) error {
+	if changes == nil {
+		changes = []Change{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(changes)
+}
+
+func renderDiffMarkdown(w io.Writer, changes []Change) error {
+	if len(changes) == 0 {
+		_, err := fmt.Fprintln(w, "No differences.")
+		return err
+	}
+
+	// The node column is only needed when nodes were paired.
+	var nodeHeader, nodeLine string
+	for _, c := range changes {
+		if c.Node != "" {
+			nodeHeader, nodeLine = "| Node ", "|------"
+			break
+		}
+	}
+	fmt.Fprintln(w, nodeHeader+"| Kind | Object | Field | Change | Left | Right |")
+	fmt.Fprintln(w, nodeLine+"|------|--------|-------|--------|------|-------|")
+	for _, c := range changes {
+		var node string
+		if nodeHeader != "" {
+			node = "| " + markdownCell(c.Node) + " "
+		}
+		_, err := fmt.Fprintf(w, "%s| %s | %s | %s | %s | %s | %s |\n",
+			node, markdownCell(c.Kind), markdownCell(c.Object), markdownCell(c.Field), c.Action, markdownCell(c.Left), markdownCell(c.Right))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func markdownCell(value string) string {
+	if value == "" {
+		return ""
+	}
+	value = strings.ReplaceAll(value, "|", "\\|")
+	value = strings.ReplaceAll(value, "\n", "<br>")
+	return "`" + strings.ReplaceAll(value, "`", "'") + "`"
+}
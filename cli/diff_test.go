@@ -0,0 +1,254 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+)
+
+func TestDiffInventories(t *testing.T) {
+	left := newTestServer()
+	right := newTestServer()
+
+	right.Modules["Master/smart_auth"] = smilecdr.ModuleConfig{
+		ModuleId:   "smart_auth",
+		ModuleType: "SECURITY_OUT_SMART",
+		Options: []smilecdr.ModuleOption{
+			{Key: "issuer.url", Value: "https://auth.example.org"},
+			{Key: "openid.signing.keystore_id", Value: "default-keystore"},
+			{Key: "openid.signing.keystore_password", Value: "right-keystore-password"},
+		},
+		Dependencies: []smilecdr.ModuleDependency{{ModuleId: "ldap_security", Type: "SECURITY_IN_UP"}},
+	}
+	delete(right.Modules, "Master/admin_json")
+	right.OpenIdClients[0].Pid = 99
+	right.OpenIdClients[0].Scopes = []string{"patient/*.read", "openid", "offline_access"}
+	right.OpenIdClients[0].ClientSecrets[0].Secret = "another-secret"
+	right.IdentityProviders[0].Issuer = "https://idp.prod.example.org/realms/main"
+	right.IdentityProviders[0].TokenIntrospectionClientSecret = "prod-idp-secret"
+
+	changes := diffInventories(smilecdr.Inventory{
+		Modules:           values(left.Modules),
+		OpenIdClients:     left.OpenIdClients,
+		IdentityProviders: left.IdentityProviders,
+	}, smilecdr.Inventory{
+		Modules:           values(right.Modules),
+		OpenIdClients:     right.OpenIdClients,
+		IdentityProviders: right.IdentityProviders,
+	})
+
+	expected := []Change{
+		{Kind: "module", Object: "admin_json", Action: actionRemoved, Left: "ADMIN_JSON"},
+		{Kind: "module", Object: "smart_auth", Field: "options.issuer.url", Action: actionChanged, Left: "http://localhost:9200", Right: "https://auth.example.org"},
+		{Kind: "module", Object: "smart_auth", Field: "options.openid.signing.keystore_password", Action: actionAdded, Right: maskedValue},
+		{Kind: "module", Object: "smart_auth", Field: "dependencies.SECURITY_IN_UP", Action: actionChanged, Left: "local_security", Right: "ldap_security"},
+		{Kind: "openid_client", Object: "smart_auth/my-app", Field: "clientSecrets", Action: actionChanged, Left: "[{secret=(masked)}]", Right: "[{secret=(masked)}]"},
+		{Kind: "openid_client", Object: "smart_auth/my-app", Field: "scopes", Action: actionChanged, Left: "[openid, patient/*.read]", Right: "[offline_access, openid, patient/*.read]"},
+		{Kind: "identity_provider", Object: "smart_auth/Corporate IdP", Field: "issuer", Action: actionChanged, Left: "https://idp.example.org/realms/main", Right: "https://idp.prod.example.org/realms/main"},
+		{Kind: "identity_provider", Object: "smart_auth/Corporate IdP", Field: "tokenIntrospectionClientSecret", Action: actionChanged, Left: maskedValue, Right: maskedValue},
+	}
+
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, got %d: %+v", len(expected), len(changes), changes)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Errorf("change %d:\nexpected %+v\n     got %+v", i, expected[i], changes[i])
+		}
+	}
+
+	for format, render := range diffFormats {
+		var out bytes.Buffer
+		if err := render(&out, changes); err != nil {
+			t.Fatalf("%s: %s", format, err)
+		}
+		for _, secret := range []string{"s3cr3t-value", "another-secret", "idp-secret-value", "prod-idp-secret", "right-keystore-password"} {
+			if strings.Contains(out.String(), secret) {
+				t.Errorf("%s output leaks the secret %q", format, secret)
+			}
+		}
+	}
+}
+
+func TestDiffCommand(t *testing.T) {
+	left := newTestServer()
+	module := left.Modules["Master/smart_auth"]
+	module.SetOption("openid.signing.keystore_password", "keystore-password-value")
+	left.Modules["Master/smart_auth"] = module
+	baseUrl := left.Start()
+	defer left.Close()
+
+	// Compare the server with the inventory saved from itself: there must be no difference.
+	saved := filepath.Join(t.TempDir(), "left.json")
+	var stdout bytes.Buffer
+	err := runDiff([]string{
+		"-left-base-url", baseUrl, "-left-username", "admin", "-left-password", "password", "-left-out", saved,
+		"-right-base-url", baseUrl, "-right-username", "admin", "-right-password", "password",
+		"-format", "json",
+	}, &stdout)
+	if err != nil {
+		t.Fatalf("diff failed: %s", err)
+	}
+	var changes []Change
+	if err := json.Unmarshal(stdout.Bytes(), &changes); err != nil || len(changes) != 0 {
+		t.Fatalf("expected no changes, got %s (%v)", stdout.String(), err)
+	}
+	content, err := os.ReadFile(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"s3cr3t-value", "idp-secret-value", "keystore-password-value"} {
+		if strings.Contains(string(content), secret) {
+			t.Errorf("the saved inventory holds the credential %q", secret)
+		}
+	}
+	inventory, err := smilecdr.ReadInventoryFile(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, module := range inventory.Modules {
+		if value, _ := module.LookupOptionOk("openid.signing.keystore_password"); value != "" {
+			t.Errorf("the saved inventory holds the keystore_password of %s", module.ModuleId)
+		}
+	}
+
+	left.OpenIdClients[0].ClientName = "Renamed"
+	stdout.Reset()
+	err = runDiff([]string{
+		"-left-file", saved,
+		"-right-base-url", baseUrl, "-right-username", "admin", "-right-password", "password",
+	}, &stdout)
+	if err != nil {
+		t.Fatalf("diff failed: %s", err)
+	}
+	if expected := `~ openid_client smart_auth/my-app clientName: "My App" -> "Renamed"`; strings.TrimSpace(stdout.String()) != expected {
+		t.Errorf("expected %q, got %q", expected, stdout.String())
+	}
+}
+
+func values(modules map[string]smilecdr.ModuleConfig) []smilecdr.ModuleConfig {
+	var result []smilecdr.ModuleConfig
+	for _, key := range unionKeys(modules) {
+		result = append(result, modules[key])
+	}
+	return result
+}
+
+func TestDiffNodeId(t *testing.T) {
+	server := newTestServer()
+	baseUrl := server.Start()
+	defer server.Close()
+
+	other := server.OpenIdClients[0]
+	other.NodeId, other.ClientId = "Node2", "node2-app"
+	server.OpenIdClients = append(server.OpenIdClients, other)
+	server.Modules["Node2/local_security"] = server.Modules["Master/local_security"]
+
+	modules := map[string]int{"Master": 3, "Node2": 1}
+	for nodeId, expected := range map[string]string{"Master": "my-app", "Node2": "node2-app"} {
+		saved := filepath.Join(t.TempDir(), nodeId+".json")
+		err := runDiff([]string{
+			"-left-base-url", baseUrl, "-left-username", "admin", "-left-password", "password", "-left-out", saved,
+			"-right-base-url", baseUrl, "-right-username", "admin", "-right-password", "password",
+			"-node-id", nodeId,
+		}, &bytes.Buffer{})
+		if err != nil {
+			t.Fatalf("diff failed: %s", err)
+		}
+		inventory, err := smilecdr.ReadInventoryFile(saved)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if inventory.NodeId != nodeId || len(inventory.OpenIdClients) != 1 || inventory.OpenIdClients[0].ClientId != expected {
+			t.Errorf("the inventory of node %s holds the clients %+v", nodeId, inventory.OpenIdClients)
+		}
+		if len(inventory.Modules) != modules[nodeId] {
+			t.Errorf("the inventory of node %s holds %d modules, expected %d", nodeId, len(inventory.Modules), modules[nodeId])
+		}
+	}
+}
+
+func TestDiffNodes(t *testing.T) {
+	left := newTestServer()
+	left.Modules["Node2/local_security"] = left.Modules["Master/local_security"]
+	leftUrl := left.Start()
+	defer left.Close()
+
+	right := newTestServer()
+	right.Modules["Node2/local_security"] = smilecdr.ModuleConfig{
+		ModuleId:   "local_security",
+		ModuleType: "SECURITY_IN_LOCAL",
+		Options: []smilecdr.ModuleOption{
+			{Key: "password_strength.min_length", Value: "8"},
+			{Key: "lockout.failed_attempts", Value: "5"},
+		},
+	}
+	right.Modules["Node3/local_security"] = right.Modules["Master/local_security"]
+	rightUrl := right.Start()
+	defer right.Close()
+
+	expected := []Change{
+		{Node: "Node2", Kind: "module", Object: "local_security", Field: "options.lockout.failed_attempts", Action: actionAdded, Right: "5"},
+		{Kind: "node", Object: "Node3", Action: actionAdded},
+	}
+
+	saved := filepath.Join(t.TempDir(), "left.json")
+	leftArgs := []string{"-left-base-url", leftUrl, "-left-username", "admin", "-left-password", "password", "-left-out", saved}
+	for _, args := range [][]string{leftArgs, {"-left-file", saved}} {
+		var stdout bytes.Buffer
+		err := runDiff(append(args,
+			"-right-base-url", rightUrl, "-right-username", "admin", "-right-password", "password",
+			"-node-id", "all", "-format", "json",
+		), &stdout)
+		if err != nil {
+			t.Fatalf("diff failed: %s", err)
+		}
+		var changes []Change
+		if err := json.Unmarshal(stdout.Bytes(), &changes); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(changes, expected) {
+			t.Errorf("expected %+v, got %+v", expected, changes)
+		}
+	}
+
+	inventory, err := smilecdr.ReadInventoryFile(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inventory.Version != smilecdr.InventoryVersion || len(inventory.Nodes) != 2 || inventory.Nodes[1].NodeId != "Node2" || len(inventory.Nodes[1].Modules) != 1 {
+		t.Errorf("unexpected saved inventory %+v", inventory)
+	}
+
+	var text, markdown bytes.Buffer
+	renderDiffText(&text, expected)
+	if !strings.Contains(text.String(), `+ node Node2 module local_security options.lockout.failed_attempts "5"`) || !strings.Contains(text.String(), "+ node Node3") {
+		t.Errorf("unexpected text output:\n%s", text.String())
+	}
+	renderDiffMarkdown(&markdown, expected)
+	if !strings.Contains(markdown.String(), "| Node | Kind |") || !strings.Contains(markdown.String(), "| `Node2` | `module` |") {
+		t.Errorf("unexpected markdown output:\n%s", markdown.String())
+	}
+
+	// A single node is paired by id with the nodes of an inventory holding several.
+	var stdout bytes.Buffer
+	err = runDiff([]string{
+		"-left-base-url", leftUrl, "-left-username", "admin", "-left-password", "password",
+		"-right-file", saved, "-node-id", "Node2",
+	}, &stdout)
+	if err != nil {
+		t.Fatalf("diff failed: %s", err)
+	}
+	if strings.TrimSpace(stdout.String()) != "+ node Master" {
+		t.Errorf("a single node is not paired with the nodes of the file:\n%s", stdout.String())
+	}
+}
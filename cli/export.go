@@ -0,0 +1,234 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zedwerks/terraform-smilecdr/provider"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+)
+
+// Module types with a dedicated resource. Any other module is exported as a smilecdr_module_config.
+var typedModuleResources = map[string]string{
+	"SECURITY_IN_SMART":  "smilecdr_smart_inbound_security",
+	"SECURITY_OUT_SMART": "smilecdr_smart_outbound_security",
+}
+
+const localSecurityModuleType = "SECURITY_IN_LOCAL"
+
+type exporter struct {
+	ctx       context.Context
+	client    *smilecdr.Client
+	resources map[string]*schema.Resource
+	config    *configuration
+	// prefix tells the resources of the nodes apart when several nodes are exported.
+	prefix bool
+	count  int
+}
+
+func runExport(args []string, stdout io.Writer) error {
+	var conn connection
+	var outDir, nodeIds string
+	var skipUsers bool
+
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	conn.register(fs, "")
+	fs.StringVar(&outDir, "out", ".", "Directory to write the generated .tf files to")
+	fs.StringVar(&nodeIds, "node-id", "Master", "Nodes of the module configurations to export"+nodeIdsUsage)
+	fs.BoolVar(&skipUsers, "skip-users", false, "Do not export the users of local security modules")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	client, err := conn.client(ctx)
+	if err != nil {
+		return err
+	}
+	selected, err := selectNodeIds(ctx, client, nodeIds)
+	if err != nil {
+		return err
+	}
+
+	e := &exporter{
+		ctx:       ctx,
+		client:    client,
+		resources: provider.Provider().ResourcesMap,
+		config:    newConfiguration(),
+		prefix:    len(selected) > 1,
+	}
+
+	localSecurityModules := map[string][]string{}
+	for _, nodeId := range selected {
+		modules, err := client.GetNodeModuleConfigs(ctx, nodeId)
+		if err != nil {
+			return fmt.Errorf("listing module configurations of node %s: %w", nodeId, err)
+		}
+		sort.Slice(modules, func(i, j int) bool { return modules[i].ModuleId < modules[j].ModuleId })
+		for _, module := range modules {
+			if err := e.exportModule(nodeId, module); err != nil {
+				return err
+			}
+			if module.ModuleType == localSecurityModuleType {
+				localSecurityModules[nodeId] = append(localSecurityModules[nodeId], module.ModuleId)
+			}
+		}
+	}
+
+	exported := map[string]bool{}
+	for _, nodeId := range selected {
+		exported[nodeId] = true
+	}
+
+	clients, err := client.GetOpenIdClients(ctx)
+	if err != nil {
+		return fmt.Errorf("listing OpenID clients: %w", err)
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].ClientId < clients[j].ClientId })
+	for _, c := range clients {
+		if !exported[c.NodeId] {
+			continue
+		}
+		id := fmt.Sprintf("%s/%s/%s", c.NodeId, c.ModuleId, c.ClientId)
+		// Secrets are masked by the server, so they are left to be managed outside of Terraform.
+		if err := e.export("openid_clients.tf", "smilecdr_openid_client", e.name(c.NodeId, c.ClientId), id, "client_secrets"); err != nil {
+			return err
+		}
+	}
+
+	providers, err := client.GetOpenIdIdentityProviders(ctx)
+	if err != nil {
+		return fmt.Errorf("listing OpenID identity providers: %w", err)
+	}
+	sort.Slice(providers, func(i, j int) bool { return providers[i].Issuer < providers[j].Issuer })
+	for _, p := range providers {
+		if !exported[p.NodeId] {
+			continue
+		}
+		id := fmt.Sprintf("%s/%s?issuer_url=%s", p.NodeId, p.ModuleId, p.Issuer)
+		if err := e.export("identity_providers.tf", "smilecdr_openid_identity_provider", e.name(p.NodeId, p.Name), id); err != nil {
+			return err
+		}
+	}
+
+	if !skipUsers {
+		for _, nodeId := range selected {
+			for _, moduleId := range localSecurityModules[nodeId] {
+				if err := e.exportUsers(nodeId, moduleId); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if err := e.write(outDir); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Exported %d resources to %s\n", e.count, outDir)
+	return nil
+}
+
+// name is the name of the resource of an object, starting with its node when several nodes are exported.
+func (e *exporter) name(nodeId string, name string) string {
+	if e.prefix {
+		return nodeId + "_" + name
+	}
+	return name
+}
+
+func (e *exporter) exportModule(nodeId string, module smilecdr.ModuleConfig) error {
+	resourceType, ok := typedModuleResources[module.ModuleType]
+	if !ok {
+		resourceType = "smilecdr_module_config"
+	}
+	id := fmt.Sprintf("%s/%s", nodeId, module.ModuleId)
+
+	return e.export("modules.tf", resourceType, e.name(nodeId, module.ModuleId), id)
+}
+
+func (e *exporter) exportUsers(nodeId string, moduleId string) error {
+	users, err := e.client.GetUsers(e.ctx, nodeId, moduleId, "")
+	if err != nil {
+		return fmt.Errorf("listing users of module %s on node %s: %w", moduleId, nodeId, err)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+
+	for _, user := range users {
+		id := fmt.Sprintf("%s/%s/%d", nodeId, moduleId, user.Pid)
+		// Passwords cannot be read back from the server.
+		if err := e.export("users.tf", "smilecdr_user", e.name(nodeId, user.Username), id, "password"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// export imports a single object through the importer of its resource, exactly as `terraform import`
+// would, and renders the resulting state as configuration.
+func (e *exporter) export(fileName string, resourceType string, name string, importId string, ignoreChanges ...string) error {
+	r := e.resources[resourceType]
+
+	d := r.Data(nil)
+	d.SetId(importId)
+
+	imported, err := r.Importer.StateContext(e.ctx, d, e.client)
+	if err != nil {
+		return fmt.Errorf("reading %s %s: %w", resourceType, importId, err)
+	}
+
+	for _, state := range imported {
+		e.config.addResource(fileName, resourceType, name, importId, r, state, ignoreChanges...)
+		e.count++
+	}
+	return nil
+}
+
+func (e *exporter) write(outDir string) error {
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return err
+	}
+
+	files := map[string][]byte{
+		"provider.tf": providerConfiguration(),
+		"imports.tf":  e.config.imports.Bytes(),
+	}
+	if len(e.config.variables.Body().Blocks()) > 0 {
+		files["variables.tf"] = e.config.variables.Bytes()
+	}
+	for name, f := range e.config.files {
+		files[name] = f.Bytes()
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(outDir, name), hclwrite.Format(content), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// providerConfiguration leaves the connection settings to the SMILECDR_* environment variables.
+func providerConfiguration() []byte {
+	f := hclwrite.NewEmptyFile()
+
+	terraform := f.Body().AppendNewBlock("terraform", nil)
+	providers := terraform.Body().AppendNewBlock("required_providers", nil)
+	providers.Body().SetAttributeValue("smilecdr", cty.ObjectVal(map[string]cty.Value{
+		"source": cty.StringVal("zedwerks/smilecdr"),
+	}))
+	f.Body().AppendNewline()
+	f.Body().AppendNewBlock("provider", []string{"smilecdr"})
+
+	return f.Bytes()
+}
@@ -0,0 +1,216 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr/smilecdrtest"
+)
+
+func newTestServer() *smilecdrtest.Server {
+	server := smilecdrtest.NewServer()
+
+	server.Modules["Master/local_security"] = smilecdr.ModuleConfig{
+		ModuleId:   "local_security",
+		ModuleType: "SECURITY_IN_LOCAL",
+		Options:    []smilecdr.ModuleOption{{Key: "password_strength.min_length", Value: "8"}},
+	}
+	server.Modules["Master/smart_auth"] = smilecdr.ModuleConfig{
+		ModuleId:   "smart_auth",
+		ModuleType: "SECURITY_OUT_SMART",
+		Options: []smilecdr.ModuleOption{
+			{Key: "issuer.url", Value: "http://localhost:9200"},
+			{Key: "openid.signing.keystore_id", Value: "default-keystore"},
+		},
+		Dependencies: []smilecdr.ModuleDependency{{ModuleId: "local_security", Type: "SECURITY_IN_UP"}},
+	}
+	server.Modules["Master/admin_json"] = smilecdr.ModuleConfig{
+		ModuleId:   "admin_json",
+		ModuleType: "ADMIN_JSON",
+		Options:    []smilecdr.ModuleOption{{Key: "port", Value: "9000"}},
+		Dependencies: []smilecdr.ModuleDependency{
+			{ModuleId: "local_security", Type: "SECURITY_IN_UP"},
+		},
+	}
+	server.OpenIdClients = []smilecdr.OpenIdClient{{
+		Pid:                         1,
+		NodeId:                      "Master",
+		ModuleId:                    "smart_auth",
+		ClientId:                    "my-app",
+		ClientName:                  "My App",
+		Enabled:                     smilecdr.Bool(true),
+		AccessTokenValiditySeconds:  smilecdr.Int(300),
+		AllowedGrantTypes:           []string{"CLIENT_CREDENTIALS", "AUTHORIZATION_CODE", "REFRESH_TOKEN"},
+		RefreshTokenValiditySeconds: smilecdr.Int(86400),
+		Scopes:                      []string{"openid", "patient/*.read"},
+		ClientSecrets:               []smilecdr.ClientSecret{{Pid: 1, Secret: "s3cr3t-value"}},
+	}}
+	server.IdentityProviders = []smilecdr.OpenIdIdentityProvider{{
+		Pid:                            2,
+		NodeId:                         "Master",
+		ModuleId:                       "smart_auth",
+		Name:                           "Corporate IdP",
+		Issuer:                         "https://idp.example.org/realms/main",
+		TokenIntrospectionClientId:     "introspect",
+		TokenIntrospectionClientSecret: "idp-secret-value",
+	}}
+	server.Users = []smilecdr.User{{
+		Pid:         3,
+		NodeId:      "Master",
+		ModuleId:    "local_security",
+		Username:    "service-account",
+		Password:    "Passw0rd-hash",
+		Authorities: []smilecdr.UserAuthorities{{Permission: "FHIR_ALL_READ"}},
+	}}
+
+	return server
+}
+
+func TestExport(t *testing.T) {
+	server := newTestServer()
+	module := server.Modules["Master/admin_json"]
+	module.SetOption("tls.keystore.password", "keystore-password-value")
+	server.Modules["Master/admin_json"] = module
+	baseUrl := server.Start()
+	defer server.Close()
+
+	outDir := t.TempDir()
+	var stdout bytes.Buffer
+	err := runExport([]string{"-base-url", baseUrl, "-username", "admin", "-password", "password", "-out", outDir}, &stdout)
+	if err != nil {
+		t.Fatalf("export failed: %s", err)
+	}
+
+	files := map[string]string{}
+	for _, name := range []string{"provider.tf", "imports.tf", "variables.tf", "modules.tf", "openid_clients.tf", "identity_providers.tf", "users.tf"} {
+		content, err := os.ReadFile(filepath.Join(outDir, name))
+		if err != nil {
+			t.Fatalf("missing %s: %s", name, err)
+		}
+		if _, diags := hclsyntax.ParseConfig(content, name, hcl.InitialPos); diags.HasErrors() {
+			t.Fatalf("%s is not valid HCL: %s\n%s", name, diags.Error(), content)
+		}
+		files[name] = string(content)
+	}
+
+	expected := map[string][]string{
+		"modules.tf": {
+			`resource "smilecdr_smart_outbound_security" "smart_auth"`,
+			`resource "smilecdr_module_config" "admin_json"`,
+			`key = "port" value = "9000"`,
+			`key = "tls.keystore.password" value = var.admin_json_options_tls_keystore_password_value`,
+			`resource "smilecdr_module_config" "local_security"`,
+		},
+		"openid_clients.tf": {
+			`resource "smilecdr_openid_client" "my_app"`,
+			`client_id`,
+			`secret = var.my_app_client_secrets_1_secret`,
+			`refresh_token_validity_seconds = 86400`,
+			`ignore_changes = [client_secrets]`,
+		},
+		"identity_providers.tf": {
+			`resource "smilecdr_openid_identity_provider" "corporate_idp"`,
+			`token_introspection_client_secret = var.corporate_idp_token_introspection_client_secret`,
+		},
+		"users.tf": {
+			`resource "smilecdr_user" "service_account"`,
+			`password = var.service_account_password`,
+			`ignore_changes = [password]`,
+		},
+		"imports.tf": {
+			`to = smilecdr_smart_outbound_security.smart_auth`,
+			`id = "Master/smart_auth"`,
+			`id = "Master/smart_auth/my-app"`,
+			`id = "Master/smart_auth?issuer_url=https://idp.example.org/realms/main"`,
+			`id = "Master/local_security/3"`,
+		},
+		"variables.tf": {
+			`variable "service_account_password"`,
+			`variable "admin_json_options_tls_keystore_password_value"`,
+			`sensitive = true`,
+		},
+	}
+	for name, snippets := range expected {
+		normalized := strings.Join(strings.Fields(files[name]), " ")
+		for _, snippet := range snippets {
+			if !strings.Contains(normalized, strings.Join(strings.Fields(snippet), " ")) {
+				t.Errorf("%s does not contain %q:\n%s", name, snippet, files[name])
+			}
+		}
+	}
+
+	for name, content := range files {
+		for _, secret := range []string{"s3cr3t-value", "idp-secret-value", "Passw0rd-hash", "keystore-password-value"} {
+			if strings.Contains(content, secret) {
+				t.Errorf("%s leaks the secret %q", name, secret)
+			}
+		}
+	}
+}
+
+func TestExportNodes(t *testing.T) {
+	server := newTestServer()
+	server.Modules["Node2/local_security"] = server.Modules["Master/local_security"]
+	other := server.OpenIdClients[0]
+	other.NodeId = "Node2"
+	server.OpenIdClients = append(server.OpenIdClients, other)
+	baseUrl := server.Start()
+	defer server.Close()
+
+	outDir := t.TempDir()
+	err := runExport([]string{"-base-url", baseUrl, "-username", "admin", "-password", "password", "-out", outDir, "-node-id", "all", "-skip-users"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("export failed: %s", err)
+	}
+
+	expected := map[string][]string{
+		"modules.tf": {
+			`resource "smilecdr_module_config" "master_local_security"`,
+			`resource "smilecdr_smart_outbound_security" "master_smart_auth"`,
+			`resource "smilecdr_module_config" "node2_local_security"`,
+		},
+		"openid_clients.tf": {
+			`resource "smilecdr_openid_client" "master_my_app"`,
+			`resource "smilecdr_openid_client" "node2_my_app"`,
+		},
+		"imports.tf": {
+			`id = "Node2/local_security"`,
+			`id = "Node2/smart_auth/my-app"`,
+		},
+	}
+	for name, snippets := range expected {
+		content, err := os.ReadFile(filepath.Join(outDir, name))
+		if err != nil {
+			t.Fatalf("missing %s: %s", name, err)
+		}
+		normalized := strings.Join(strings.Fields(string(content)), " ")
+		for _, snippet := range snippets {
+			if !strings.Contains(normalized, strings.Join(strings.Fields(snippet), " ")) {
+				t.Errorf("%s does not contain %q:\n%s", name, snippet, content)
+			}
+		}
+	}
+
+	// A single node keeps the names of its objects, and leaves out the objects of the other nodes.
+	outDir = t.TempDir()
+	err = runExport([]string{"-base-url", baseUrl, "-username", "admin", "-password", "password", "-out", outDir, "-node-id", "Node2", "-skip-users"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("export failed: %s", err)
+	}
+	content, err := os.ReadFile(filepath.Join(outDir, "imports.tf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "Master/") || !strings.Contains(string(content), "smilecdr_module_config.local_security") {
+		t.Errorf("unexpected imports of node Node2:\n%s", content)
+	}
+}
@@ -0,0 +1,276 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package cli
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+)
+
+// Attributes holding credentials. They are never written to the generated configuration:
+// each one is replaced by a reference to a sensitive input variable.
+var secretAttributePattern = regexp.MustCompile(`(^|_)(password|secret)$`)
+
+func isSecretAttribute(name string) bool {
+	return secretAttributePattern.MatchString(name)
+}
+
+// isCredentialOption reports whether a key and value block, such as the options of smilecdr_module_config,
+// holds a credential option, e.g. tls.keystore.password, whose value is written as an input variable too.
+func isCredentialOption(get func(string) interface{}) bool {
+	key, ok := get("key").(string)
+	return ok && smilecdr.IsCredentialName(key)
+}
+
+// Attributes written when they hold their default value, because the plan fails when they are left out:
+// smilecdr_openid_client requires refresh_token_validity_seconds with the REFRESH_TOKEN grant type.
+var explicitAttributes = map[string]bool{
+	"refresh_token_validity_seconds": true,
+}
+
+var identifierPattern = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// labels hands out unique Terraform identifiers, derived from server names and ids.
+type labels map[string]bool
+
+func (l labels) next(name string) string {
+	label := strings.Trim(identifierPattern.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if label == "" {
+		label = "unnamed"
+	}
+	if label[0] >= '0' && label[0] <= '9' {
+		label = "_" + label
+	}
+
+	unique := label
+	for i := 2; l[unique]; i++ {
+		unique = fmt.Sprintf("%s_%d", label, i)
+	}
+	l[unique] = true
+	return unique
+}
+
+// configuration accumulates the generated resources, import blocks and input variables.
+type configuration struct {
+	files     map[string]*hclwrite.File
+	imports   *hclwrite.File
+	variables *hclwrite.File
+	labels    labels
+}
+
+func newConfiguration() *configuration {
+	return &configuration{
+		files:     map[string]*hclwrite.File{},
+		imports:   hclwrite.NewEmptyFile(),
+		variables: hclwrite.NewEmptyFile(),
+		labels:    labels{},
+	}
+}
+
+func (c *configuration) file(name string) *hclwrite.File {
+	f, ok := c.files[name]
+	if !ok {
+		f = hclwrite.NewEmptyFile()
+		c.files[name] = f
+	}
+	return f
+}
+
+func (c *configuration) variable(name string) hclwrite.Tokens {
+	body := c.variables.Body()
+	if len(body.Blocks()) > 0 {
+		body.AppendNewline()
+	}
+	block := body.AppendNewBlock("variable", []string{name})
+	block.Body().SetAttributeTraversal("type", hcl.Traversal{hcl.TraverseRoot{Name: "string"}})
+	block.Body().SetAttributeValue("sensitive", cty.True)
+
+	return hclwrite.TokensForTraversal(hcl.Traversal{
+		hcl.TraverseRoot{Name: "var"},
+		hcl.TraverseAttr{Name: name},
+	})
+}
+
+// addResource renders the state read by the provider as a resource block, along with its import block.
+// Attributes left at their default value are omitted, as are computed-only attributes.
+func (c *configuration) addResource(fileName string, resourceType string, name string, importId string, r *schema.Resource, d *schema.ResourceData, ignoreChanges ...string) string {
+	label := c.labels.next(name)
+
+	body := c.file(fileName).Body()
+	if len(body.Blocks()) > 0 {
+		body.AppendNewline()
+	}
+	block := body.AppendNewBlock("resource", []string{resourceType, label})
+	c.writeAttributes(block.Body(), label, r.Schema, func(key string) interface{} { return d.Get(key) })
+
+	if len(ignoreChanges) > 0 {
+		var traversals []hclwrite.Tokens
+		for _, attribute := range ignoreChanges {
+			traversals = append(traversals, hclwrite.TokensForTraversal(hcl.Traversal{hcl.TraverseRoot{Name: attribute}}))
+		}
+		block.Body().AppendNewline()
+		lifecycle := block.Body().AppendNewBlock("lifecycle", nil)
+		lifecycle.Body().SetAttributeRaw("ignore_changes", hclwrite.TokensForTuple(traversals))
+	}
+
+	imports := c.imports.Body()
+	if len(imports.Blocks()) > 0 {
+		imports.AppendNewline()
+	}
+	importBlock := imports.AppendNewBlock("import", nil)
+	importBlock.Body().SetAttributeTraversal("to", hcl.Traversal{
+		hcl.TraverseRoot{Name: resourceType},
+		hcl.TraverseAttr{Name: label},
+	})
+	importBlock.Body().SetAttributeValue("id", cty.StringVal(importId))
+
+	return label
+}
+
+func (c *configuration) writeAttributes(body *hclwrite.Body, prefix string, schemaMap map[string]*schema.Schema, get func(string) interface{}) {
+	keys := make([]string, 0, len(schemaMap))
+	for key := range schemaMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// Arguments first, nested blocks last, as they are usually written by hand.
+	var blocks []string
+	for _, key := range keys {
+		s := schemaMap[key]
+		if s.Computed && !s.Optional && !s.Required {
+			continue
+		}
+		value := get(key)
+
+		if _, nested := s.Elem.(*schema.Resource); nested {
+			blocks = append(blocks, key)
+			continue
+		}
+		if !s.Required && isDefaultValue(s, value) && !(explicitAttributes[key] && value == s.Default) {
+			continue
+		}
+		if isSecretAttribute(key) || (key == "value" && value != "" && isCredentialOption(get)) {
+			body.SetAttributeRaw(key, c.variable(prefix+"_"+key))
+			continue
+		}
+		body.SetAttributeValue(key, toCtyValue(s, value))
+	}
+
+	for _, key := range blocks {
+		s := schemaMap[key]
+		nested := s.Elem.(*schema.Resource)
+		for i, item := range collectionItems(get(key)) {
+			values, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			block := body.AppendNewBlock(key, nil)
+			blockPrefix := fmt.Sprintf("%s_%s_%d", prefix, key, i+1)
+			if option, ok := values["key"].(string); ok && smilecdr.IsCredentialName(option) {
+				blockPrefix = prefix + "_" + key + "_" + strings.Trim(identifierPattern.ReplaceAllString(strings.ToLower(option), "_"), "_")
+			}
+			c.writeAttributes(block.Body(), blockPrefix, nested.Schema, func(k string) interface{} { return values[k] })
+		}
+	}
+}
+
+func collectionItems(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case *schema.Set:
+		return v.List()
+	case []interface{}:
+		return v
+	}
+	return nil
+}
+
+func isDefaultValue(s *schema.Schema, value interface{}) bool {
+	if s.Default != nil && reflect.DeepEqual(s.Default, value) {
+		return true
+	}
+	// An empty string or zero the server did not return is left to the default, while an
+	// explicit false has to be written when the default is true.
+	if _, ok := value.(bool); ok && s.Default != nil {
+		return false
+	}
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case int:
+		return v == 0
+	case float64:
+		return v == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return len(collectionItems(value)) == 0
+}
+
+func toCtyValue(s *schema.Schema, value interface{}) cty.Value {
+	switch s.Type {
+	case schema.TypeString:
+		v, _ := value.(string)
+		return cty.StringVal(v)
+	case schema.TypeBool:
+		v, _ := value.(bool)
+		return cty.BoolVal(v)
+	case schema.TypeInt:
+		v, _ := value.(int)
+		return cty.NumberIntVal(int64(v))
+	case schema.TypeFloat:
+		v, _ := value.(float64)
+		return cty.NumberFloatVal(v)
+	case schema.TypeList, schema.TypeSet:
+		elem, _ := s.Elem.(*schema.Schema)
+		if elem == nil {
+			elem = &schema.Schema{Type: schema.TypeString}
+		}
+		var values []cty.Value
+		for _, item := range collectionItems(value) {
+			values = append(values, toCtyValue(elem, item))
+		}
+		if len(values) == 0 {
+			return cty.ListValEmpty(cty.String)
+		}
+		if s.Type == schema.TypeSet {
+			sortCtyValues(values)
+		}
+		return cty.TupleVal(values)
+	case schema.TypeMap:
+		m, _ := value.(map[string]interface{})
+		values := map[string]cty.Value{}
+		for k, v := range m {
+			values[k] = cty.StringVal(fmt.Sprint(v))
+		}
+		if len(values) == 0 {
+			return cty.MapValEmpty(cty.String)
+		}
+		return cty.ObjectVal(values)
+	}
+	return cty.NullVal(cty.DynamicPseudoType)
+}
+
+// Sets come back in hash order, sorting them keeps the generated files stable between runs.
+func sortCtyValues(values []cty.Value) {
+	sort.SliceStable(values, func(i, j int) bool {
+		if values[i].Type() == cty.String && values[j].Type() == cty.String {
+			return values[i].AsString() < values[j].AsString()
+		}
+		return false
+	})
+}
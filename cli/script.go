@@ -0,0 +1,214 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package cli
+
+import (
+	_ "embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/zedwerks/terraform-smilecdr/provider/helper/validations"
+)
+
+//go:embed script_environment.js
+var scriptEnvironment string
+
+// The longest a script may run before it is interrupted, e.g. in an endless loop.
+const scriptTimeout = 10 * time.Second
+
+func runScript(args []string, stdout io.Writer) error {
+	var hookName, fixtureFile, expectFile string
+
+	fs := flag.NewFlagSet("script", flag.ContinueOnError)
+	fs.StringVar(&hookName, "hook", "", "Callback hook to run the script as: "+strings.Join(scriptHookNames(), ", "))
+	fs.StringVar(&fixtureFile, "fixture", "", "JSON file of the inputs passed to the script: userSession, clientDetails, authorizationRequest, contextSelectionChoices, outcome, context, userInfo, serverInfo and environment")
+	fs.StringVar(&expectFile, "expect", "", "JSON file of the expected result; the command fails when a field it holds differs")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: terraform-provider-smilecdr script -hook <hook> [-fixture file] [-expect file] <script.js>")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Hooks:")
+		for _, name := range scriptHookNames() {
+			hook := validations.ScriptHooks[name]
+			fmt.Fprintf(fs.Output(), "  %-22s %s, calling %s\n", name, hook.Summary, strings.Join(hook.Functions, ", "))
+		}
+		fmt.Fprintln(fs.Output())
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected the path of one script")
+	}
+
+	script, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	fixture := map[string]interface{}{}
+	if fixtureFile != "" {
+		if err := readJSONFile(fixtureFile, &fixture); err != nil {
+			return err
+		}
+	}
+
+	result, err := runCallbackScript(hookName, fs.Arg(0), string(script), fixture)
+	if err != nil {
+		return err
+	}
+	output, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, string(output))
+
+	if expectFile != "" {
+		var expected interface{}
+		if err := readJSONFile(expectFile, &expected); err != nil {
+			return err
+		}
+		if mismatches := matchExpected("", expected, result); len(mismatches) > 0 {
+			return fmt.Errorf("the result differs from %s:\n  %s", expectFile, strings.Join(mismatches, "\n  "))
+		}
+	}
+	return nil
+}
+
+// scriptHookNames lists the hooks calling functions of the script, which script_environment.js runs.
+// A hook running the script as a whole, e.g. CODAP authorization, has nothing to call.
+func scriptHookNames() []string {
+	var names []string
+	for name, hook := range validations.ScriptHooks {
+		if len(hook.Functions) > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runCallbackScript runs a callback script as Smile CDR would for the hook, against objects built from
+// the fixture, and returns what the callbacks set and logged.
+func runCallbackScript(hookName string, name string, script string, fixture map[string]interface{}) (map[string]interface{}, error) {
+	hook, ok := validations.ScriptHooks[hookName]
+	if !ok || len(hook.Functions) == 0 {
+		return nil, fmt.Errorf("unknown hook %q, expected one of %s", hookName, strings.Join(scriptHookNames(), ", "))
+	}
+	if fixture == nil {
+		fixture = map[string]interface{}{}
+	}
+	fixtureJSON, err := json.Marshal(fixture)
+	if err != nil {
+		return nil, err
+	}
+
+	vm := goja.New()
+	timer := time.AfterFunc(scriptTimeout, func() {
+		vm.Interrupt(fmt.Sprintf("the script did not complete within %s", scriptTimeout))
+	})
+	defer timer.Stop()
+
+	if _, err := vm.RunScript("script_environment.js", scriptEnvironment); err != nil {
+		return nil, err
+	}
+	environment := vm.Get("__environment").ToObject(vm)
+
+	globals, _ := goja.AssertFunction(environment.Get("globals"))
+	fixtureValue, err := vm.RunString("(" + string(fixtureJSON) + ")")
+	if err != nil {
+		return nil, err
+	}
+	values, err := globals(environment, fixtureValue)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range values.ToObject(vm).Keys() {
+		if err := vm.Set(key, values.ToObject(vm).Get(key)); err != nil {
+			return nil, err
+		}
+	}
+
+	program, err := goja.Compile(name, script, false)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := vm.RunProgram(program); err != nil {
+		return nil, fmt.Errorf("the script failed: %w", err)
+	}
+
+	callbacks := vm.NewObject()
+	declared := false
+	for _, function := range hook.Functions {
+		if _, ok := goja.AssertFunction(vm.Get(function)); ok {
+			callbacks.Set(function, vm.Get(function))
+			declared = true
+		}
+	}
+	if !declared {
+		return nil, fmt.Errorf("the script declares none of the functions of the %s hook: %s", hookName, strings.Join(hook.Functions, ", "))
+	}
+
+	run, _ := goja.AssertFunction(environment.Get("run"))
+	output, err := run(environment, vm.ToValue(hookName), fixtureValue, callbacks)
+	if err != nil {
+		return nil, fmt.Errorf("the script failed: %w", err)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(output.String()), &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// matchExpected compares the fields of the expected JSON to those of the actual result, ignoring fields
+// the expected JSON leaves out, and returns the fields that differ.
+func matchExpected(path string, expected interface{}, actual interface{}) []string {
+	expectedObject, ok := expected.(map[string]interface{})
+	if !ok {
+		if reflect.DeepEqual(expected, actual) {
+			return nil
+		}
+		expectedJSON, _ := json.Marshal(expected)
+		actualJSON, _ := json.Marshal(actual)
+		return []string{fmt.Sprintf("%s: expected %s, got %s", path, expectedJSON, actualJSON)}
+	}
+
+	actualObject, _ := actual.(map[string]interface{})
+	keys := make([]string, 0, len(expectedObject))
+	for key := range expectedObject {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var mismatches []string
+	for _, key := range keys {
+		field := key
+		if path != "" {
+			field = path + "." + key
+		}
+		mismatches = append(mismatches, matchExpected(field, expectedObject[key], actualObject[key])...)
+	}
+	return mismatches
+}
+
+func readJSONFile(path string, v interface{}) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(content, v); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
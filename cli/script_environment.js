@@ -0,0 +1,183 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+// The objects Smile CDR passes to callback scripts, mimicked for the `script` command from the fixture
+// inputs. They hold what the callbacks read and record what they set; services reaching out of the
+// script (Http, Fhir) are not available and throw.
+var __environment = (function () {
+    var log = [];
+
+    function methods(object, functions) {
+        Object.keys(functions).forEach(function (name) {
+            Object.defineProperty(object, name, { value: functions[name], enumerable: false });
+        });
+        return object;
+    }
+
+    function logger(level) {
+        return function (message) {
+            log.push(level + ": " + message);
+        };
+    }
+
+    function unavailable(service) {
+        return function () {
+            throw new Error(service + " is not available when running a script locally");
+        };
+    }
+
+    function hasScope(scopes, scope) {
+        return scopes.indexOf(scope) >= 0;
+    }
+
+    function addAuthority(object) {
+        return function (permission, argument) {
+            var authority = { permission: permission };
+            if (argument !== undefined && argument !== null) {
+                authority.argument = String(argument);
+            }
+            object.authorities.push(authority);
+        };
+    }
+
+    function addLaunchResourceId(object) {
+        return function (resourceType, resourceId) {
+            object.launchResourceIds.push({ resourceType: resourceType, resourceId: String(resourceId) });
+        };
+    }
+
+    // theClientDetails
+    function clientDetails(data) {
+        var client = Object.assign({ clientId: null, clientName: null, scopes: [], allowedGrantTypes: [] }, data);
+        return methods(client, {
+            getClientId: function () { return client.clientId; },
+            getClientName: function () { return client.clientName; },
+            getScopes: function () { return client.scopes; },
+            getAllowedGrantTypes: function () { return client.allowedGrantTypes; },
+        });
+    }
+
+    // theUserSession
+    function userSession(data) {
+        var session = Object.assign({
+            username: null, familyName: null, givenName: null, email: null, external: false, fhirUserUrl: null,
+            approvedScopes: [], launchResourceIds: [], authorities: [],
+        }, data);
+        return methods(session, {
+            getUsername: function () { return session.username; },
+            setUsername: function (username) { session.username = username; },
+            getFamilyName: function () { return session.familyName; },
+            getGivenName: function () { return session.givenName; },
+            getEmail: function () { return session.email; },
+            isExternal: function () { return session.external; },
+            getFhirUserUrl: function () { return session.fhirUserUrl; },
+            setFhirUserUrl: function (url) { session.fhirUserUrl = url; },
+            getApprovedScopes: function () { return session.approvedScopes; },
+            hasApprovedScope: function (scope) { return hasScope(session.approvedScopes, scope); },
+            getLaunchResourceIds: function () { return session.launchResourceIds; },
+            addLaunchResourceId: addLaunchResourceId(session),
+            addAuthority: addAuthority(session),
+        });
+    }
+
+    // theAuthorizationRequestDetails
+    function authorizationRequest(data, client) {
+        var request = Object.assign({ clientId: client.clientId, memberId: null, launch: null, requestedScopes: [], accessTokenClaims: {} }, data);
+        return methods(request, {
+            getClientId: function () { return request.clientId; },
+            getMemberId: function () { return request.memberId; },
+            getLaunch: function () { return request.launch; },
+            getRequestedScopes: function () { return request.requestedScopes; },
+            getClientDetails: function () { return client; },
+            addAccessTokenClaim: function (name, value) { request.accessTokenClaims[name] = value; },
+        });
+    }
+
+    // theOutcome
+    function outcome(data) {
+        var result = Object.assign({ success: true, username: null, authorities: [], launchResourceIds: [] }, data);
+        return methods(result, {
+            getUsername: function () { return result.username; },
+            setUsername: function (username) { result.username = username; },
+            getAuthorities: function () { return result.authorities; },
+            addAuthority: addAuthority(result),
+            addLaunchResourceId: addLaunchResourceId(result),
+        });
+    }
+
+    // theOutcomeFactory
+    function outcomeFactory(theOutcome) {
+        return methods({}, {
+            newSuccess: function () { return outcome({ username: theOutcome.username }); },
+            newFailure: function (message) { return outcome({ success: false, username: theOutcome.username, message: message === undefined ? null : String(message) }); },
+        });
+    }
+
+    // theContext
+    function context(data, client, session) {
+        var ctx = Object.assign({ claims: {}, approvedScopes: [] }, data);
+        return methods(ctx, {
+            getClaim: function (name) { return ctx.claims[name] === undefined ? null : ctx.claims[name]; },
+            getClaims: function () { return ctx.claims; },
+            getApprovedScopes: function () { return ctx.approvedScopes; },
+            hasApprovedScope: function (scope) { return hasScope(ctx.approvedScopes, scope); },
+            getClientDetails: function () { return client; },
+            getUserSession: function () { return session; },
+        });
+    }
+
+    function globals(fixture) {
+        var environment = fixture.environment || {};
+        return {
+            Log: { trace: logger("TRACE"), debug: logger("DEBUG"), info: logger("INFO"), warn: logger("WARN"), error: logger("ERROR") },
+            println: logger("PRINT"),
+            Environment: { getEnv: function (name) { return environment[name] === undefined ? null : environment[name]; } },
+            Http: { get: unavailable("Http"), post: unavailable("Http"), put: unavailable("Http"), delete: unavailable("Http") },
+            Fhir: { search: unavailable("Fhir"), create: unavailable("Fhir"), read: unavailable("Fhir"), update: unavailable("Fhir") },
+        };
+    }
+
+    // The hooks call the callbacks the script declares, in the order Smile CDR does, and return what they set.
+    var hooks = {
+        "post-authorize": function (fixture, callbacks) {
+            var client = clientDetails(fixture.clientDetails);
+            var session = userSession(fixture.userSession);
+            var request = authorizationRequest(fixture.authorizationRequest, client);
+            // The choices are passed as given by the fixture, and returned as the script left them.
+            var choices = Object.assign({}, fixture.contextSelectionChoices);
+            if (callbacks.onSmartLoginPreContextSelection) {
+                callbacks.onSmartLoginPreContextSelection(session, choices);
+            }
+            if (callbacks.onTokenGenerating) {
+                callbacks.onTokenGenerating(session, request);
+            }
+            if (callbacks.onPostAuthorize) {
+                callbacks.onPostAuthorize(methods({ grantedScopes: session.approvedScopes }, {
+                    getClientDetails: function () { return client; },
+                    getUserSession: function () { return session; },
+                }));
+            }
+            return { userSession: session, contextSelectionChoices: choices, accessTokenClaims: request.accessTokenClaims };
+        },
+        "authenticate-success": function (fixture, callbacks) {
+            var client = clientDetails(fixture.clientDetails);
+            var session = userSession(fixture.userSession);
+            var theOutcome = outcome(fixture.outcome);
+            var result = callbacks.onAuthenticateSuccess(theOutcome, outcomeFactory(theOutcome), context(fixture.context, client, session));
+            return { outcome: result === undefined ? null : result };
+        },
+        "user-mapping": function (fixture, callbacks) {
+            var result = callbacks.getUserName(fixture.userInfo || {}, fixture.serverInfo || {});
+            return { username: result === undefined ? null : result };
+        },
+    };
+
+    return {
+        globals: globals,
+        run: function (hook, fixture, callbacks) {
+            var result = hooks[hook](fixture, callbacks);
+            result.log = log;
+            return JSON.stringify(result);
+        },
+    };
+})();
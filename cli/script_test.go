@@ -0,0 +1,136 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestScriptExamples runs the example scripts against their fixtures, as a CI job would.
+func TestScriptExamples(t *testing.T) {
+	examples := []struct {
+		hook    string
+		script  string
+		fixture string
+	}{
+		{"authenticate-success", "idp/on_auth_success.js", "idp_on_auth_success"},
+		{"user-mapping", "idp/user_mapping.js", "idp_user_mapping"},
+		{"authenticate-success", "smart_inbound_security/authentication_callback.js", "smart_inbound_authentication_callback"},
+		{"post-authorize", "smart_outbound_security/post_authorize_callback.js", "smart_outbound_post_authorize_callback"},
+	}
+	dir := filepath.Join("..", "example", "js")
+
+	for _, example := range examples {
+		var stdout bytes.Buffer
+		err := runScript([]string{
+			"-hook", example.hook,
+			"-fixture", filepath.Join(dir, "fixtures", example.fixture+".json"),
+			"-expect", filepath.Join(dir, "fixtures", example.fixture+".expected.json"),
+			filepath.Join(dir, example.script),
+		}, &stdout)
+		if err != nil {
+			t.Errorf("%s: %s\n%s", example.script, err, stdout.String())
+		}
+	}
+}
+
+func TestRunCallbackScript(t *testing.T) {
+	const callback = `
+function onTokenGenerating(theUserSession, theAuthorizationRequestDetails) {
+    var launch = theAuthorizationRequestDetails.getLaunch();
+    if (launch === 'remote') {
+        Http.get('https://context.example.org/' + launch);
+    }
+    theUserSession.addLaunchResourceId('Patient', launch);
+    theAuthorizationRequestDetails.addAccessTokenClaim('patient', launch);
+    Log.info('launch ' + launch + ' for ' + theUserSession.getUsername() + ' with ' + Environment.getEnv('CONTEXT_URL'));
+}`
+	fixture := map[string]interface{}{
+		"userSession":          map[string]interface{}{"username": "jdoe"},
+		"authorizationRequest": map[string]interface{}{"launch": "P123"},
+		"environment":          map[string]interface{}{"CONTEXT_URL": "https://context.example.org"},
+	}
+
+	result, err := runCallbackScript("post-authorize", "callback.js", callback, fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"userSession":       map[string]interface{}{"launchResourceIds": []interface{}{map[string]interface{}{"resourceType": "Patient", "resourceId": "P123"}}},
+		"accessTokenClaims": map[string]interface{}{"patient": "P123"},
+		"log":               []interface{}{"INFO: launch P123 for jdoe with https://context.example.org"},
+	}
+	if mismatches := matchExpected("", expected, result); len(mismatches) > 0 {
+		t.Errorf("unexpected result: %s", strings.Join(mismatches, ", "))
+	}
+
+	expected["accessTokenClaims"] = map[string]interface{}{"patient": "P456"}
+	if mismatches := matchExpected("", expected, result); len(mismatches) != 1 || mismatches[0] != `accessTokenClaims.patient: expected "P456", got "P123"` {
+		t.Errorf("unexpected mismatches %q", mismatches)
+	}
+
+	failures := []struct {
+		hook    string
+		script  string
+		fixture map[string]interface{}
+		err     string
+	}{
+		{"post-authorize", callback, map[string]interface{}{"authorizationRequest": map[string]interface{}{"launch": "remote"}}, "Http is not available when running a script locally"},
+		{"post-authorize", "function onTokenGenerating(theUserSession {}", nil, "SyntaxError: callback.js: Line 1:43"},
+		{"authenticate-success", callback, nil, "the script declares none of the functions of the authenticate-success hook: onAuthenticateSuccess"},
+		{"pre-authorize", callback, nil, `unknown hook "pre-authorize"`},
+		{"codap-authorization", callback, nil, `unknown hook "codap-authorization"`},
+	}
+	for _, failure := range failures {
+		_, err := runCallbackScript(failure.hook, "callback.js", failure.script, failure.fixture)
+		if err == nil || !strings.Contains(err.Error(), failure.err) {
+			t.Errorf("expected an error containing %q, got %v", failure.err, err)
+		}
+	}
+}
+
+func TestRunContextSelectionScript(t *testing.T) {
+	const callback = `
+function onSmartLoginPreContextSelection(theUserSession, theContextSelectionChoices) {
+    theContextSelectionChoices.patients = ['P123', 'P456'];
+    Log.info('choices for ' + theUserSession.getUsername());
+}`
+	fixture := map[string]interface{}{
+		"userSession":             map[string]interface{}{"username": "jdoe"},
+		"contextSelectionChoices": map[string]interface{}{"required": true},
+	}
+
+	result, err := runCallbackScript("post-authorize", "callback.js", callback, fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"contextSelectionChoices": map[string]interface{}{"required": true, "patients": []interface{}{"P123", "P456"}},
+		"log":                     []interface{}{"INFO: choices for jdoe"},
+	}
+	if mismatches := matchExpected("", expected, result); len(mismatches) > 0 {
+		t.Errorf("unexpected result: %s", strings.Join(mismatches, ", "))
+	}
+}
+
+func TestScriptCommandExpect(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "user_mapping.js")
+	expect := filepath.Join(dir, "expected.json")
+	os.WriteFile(script, []byte(`function getUserName(theOidcUserInfoMap, theServerInfo) { return "EXT:" + theOidcUserInfoMap.sub; }`), 0o644)
+	os.WriteFile(expect, []byte(`{"username": "EXT:someone-else"}`), 0o644)
+
+	var stdout bytes.Buffer
+	err := runScript([]string{"-hook", "user-mapping", "-expect", expect, script}, &stdout)
+	if err == nil || !strings.Contains(err.Error(), `username: expected "EXT:someone-else", got "EXT:undefined"`) {
+		t.Errorf("expected the result to differ, got %v", err)
+	}
+	if !strings.Contains(stdout.String(), `"username": "EXT:undefined"`) {
+		t.Errorf("expected the result on stdout, got %s", stdout.String())
+	}
+}
@@ -0,0 +1,338 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package cli
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+)
+
+func runSnapshot(args []string, stdout io.Writer) error {
+	var conn connection
+	var out, nodeIds string
+	var skipUsers bool
+
+	fs := flag.NewFlagSet("snapshot", flag.ContinueOnError)
+	conn.register(fs, "")
+	fs.StringVar(&out, "out", "", "Archive file to write (required)")
+	fs.StringVar(&nodeIds, "node-id", "Master", "Nodes of the module configurations to save"+nodeIdsUsage)
+	fs.BoolVar(&skipUsers, "skip-users", false, "Do not save the users of local security modules")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if out == "" {
+		return fmt.Errorf("missing -out: the archive file to write")
+	}
+
+	ctx := context.Background()
+	client, err := conn.client(ctx)
+	if err != nil {
+		return err
+	}
+	selected, err := selectNodeIds(ctx, client, nodeIds)
+	if err != nil {
+		return err
+	}
+
+	var nodes []smilecdr.Inventory
+	var modules, clients, providers, users int
+	for _, nodeId := range selected {
+		inventory, err := client.GetInventory(ctx, nodeId)
+		if err != nil {
+			return fmt.Errorf("node %s: %w", nodeId, err)
+		}
+		if !skipUsers {
+			for _, module := range inventory.Modules {
+				if module.ModuleType != localSecurityModuleType {
+					continue
+				}
+				moduleUsers, err := client.GetUsers(ctx, nodeId, module.ModuleId, "")
+				if err != nil {
+					return fmt.Errorf("listing users of module %s on node %s: %w", module.ModuleId, nodeId, err)
+				}
+				inventory.Users = append(inventory.Users, moduleUsers...)
+			}
+		}
+		nodes = append(nodes, inventory)
+		modules, clients, providers, users = modules+len(inventory.Modules), clients+len(inventory.OpenIdClients), providers+len(inventory.IdentityProviders), users+len(inventory.Users)
+	}
+	inventory := smilecdr.ClusterInventory(nodes)
+	inventory.RemoveCredentials()
+
+	if err := smilecdr.WriteInventoryFile(out, inventory); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Saved %d modules, %d OpenID clients, %d identity providers and %d users of %s to %s\n",
+		modules, clients, providers, users, strings.Join(selected, ", "), out)
+	return nil
+}
+
+type restorer struct {
+	ctx          context.Context
+	client       *smilecdr.Client
+	skipExisting bool
+
+	restored, skipped int
+	resetNeeded       []string
+}
+
+// nodeRestore is the part of an archive restored to a single node.
+type nodeRestore struct {
+	nodeId    string
+	inventory smilecdr.Inventory
+	modules   []smilecdr.ModuleConfig // in creation order
+	existing  map[string]bool         // module ids present on the node
+}
+
+func runRestore(args []string, stdout io.Writer) error {
+	var conn connection
+	var file, nodeId string
+	var skipExisting bool
+
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	conn.register(fs, "")
+	fs.StringVar(&file, "file", "", "Archive file written by the snapshot command (required)")
+	fs.StringVar(&nodeId, "node-id", "", "Node to restore the module configurations to, by default the node they were saved from. Only for an archive of a single node")
+	fs.BoolVar(&skipExisting, "skip-existing", false, "Leave modules that already exist on the server untouched, instead of failing")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if file == "" {
+		return fmt.Errorf("missing -file: the archive file to restore")
+	}
+
+	inventory, err := smilecdr.ReadInventoryFile(file)
+	if err != nil {
+		return err
+	}
+	nodes := inventory.NodeInventories()
+	if nodeId != "" && len(nodes) > 1 {
+		return fmt.Errorf("-node-id cannot be used with an archive of %d nodes, which are restored to the nodes they were saved from", len(nodes))
+	}
+
+	ctx := context.Background()
+	client, err := conn.client(ctx)
+	if err != nil {
+		return err
+	}
+
+	r := &restorer{
+		ctx:          ctx,
+		client:       client,
+		skipExisting: skipExisting,
+	}
+	// Every node is checked before anything is created, so that a conflict leaves the server untouched.
+	var restores []*nodeRestore
+	for _, node := range nodes {
+		target := nodeId
+		if target == "" {
+			target = node.NodeId
+		}
+		if target == "" {
+			target = "Master"
+		}
+		n, err := r.prepare(target, node)
+		if err != nil {
+			return err
+		}
+		restores = append(restores, n)
+	}
+	for _, n := range restores {
+		if err := r.restore(n); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(stdout, "Restored %d objects to %s, skipped %d existing modules\n", r.restored, conn.baseUrl, r.skipped)
+	if len(r.resetNeeded) > 0 {
+		fmt.Fprintln(stdout, "Credentials are not archived. These were set to random values, or left blank for module options, and must be set again:")
+		for _, name := range r.resetNeeded {
+			fmt.Fprintf(stdout, "  %s\n", name)
+		}
+	}
+	return nil
+}
+
+// prepare orders the modules of a node so that each one is created after the modules it depends on,
+// and checks that none of them exists on the node yet, unless existing modules are skipped.
+func (r *restorer) prepare(nodeId string, inventory smilecdr.Inventory) (*nodeRestore, error) {
+	n := &nodeRestore{nodeId: nodeId, inventory: inventory, existing: map[string]bool{}}
+
+	current, err := r.client.GetNodeModuleConfigs(r.ctx, nodeId)
+	if err != nil {
+		return nil, fmt.Errorf("listing module configurations of node %s: %w", nodeId, err)
+	}
+	for _, module := range current {
+		n.existing[module.ModuleId] = true
+	}
+
+	n.modules, err = orderModules(inventory.Modules, n.existing)
+	if err != nil {
+		return nil, err
+	}
+	if !r.skipExisting {
+		var conflicts []string
+		for _, module := range n.modules {
+			if n.existing[module.ModuleId] {
+				conflicts = append(conflicts, module.ModuleId)
+			}
+		}
+		if len(conflicts) > 0 {
+			return nil, fmt.Errorf("modules already exist on node %s: %s; restore into an empty server, or use -skip-existing", nodeId, strings.Join(conflicts, ", "))
+		}
+	}
+	return n, nil
+}
+
+// restore creates the objects of a node: its modules, then the identity providers, OpenID clients
+// and users of those modules.
+func (r *restorer) restore(n *nodeRestore) error {
+	for _, module := range n.modules {
+		if n.existing[module.ModuleId] {
+			r.skipped++
+			continue
+		}
+		for _, option := range module.Options {
+			if option.Value == "" && smilecdr.IsCredentialName(option.Key) {
+				r.resetNeeded = append(r.resetNeeded, fmt.Sprintf("module %s: option %s", module.ModuleId, option.Key))
+			}
+		}
+		if _, err := r.client.PostModuleConfig(r.ctx, n.nodeId, module); err != nil {
+			return fmt.Errorf("creating module %s: %w", module.ModuleId, err)
+		}
+		r.restored++
+	}
+
+	for _, provider := range n.inventory.IdentityProviders {
+		provider.Pid = 0
+		provider.NodeId = n.nodeId
+		if provider.TokenIntrospectionClientSecret == "" && provider.TokenIntrospectionClientId != "" {
+			r.resetNeeded = append(r.resetNeeded, fmt.Sprintf("identity provider %s/%s: token introspection client secret", provider.ModuleId, provider.Name))
+		}
+		if _, err := r.client.PostOpenIdIdentityProvider(r.ctx, provider); err != nil {
+			return fmt.Errorf("creating identity provider %s: %w", provider.Name, err)
+		}
+		r.restored++
+	}
+
+	for _, client := range n.inventory.OpenIdClients {
+		client.Pid = 0
+		client.NodeId = n.nodeId
+		secrets := make([]smilecdr.ClientSecret, len(client.ClientSecrets))
+		for i, secret := range client.ClientSecrets {
+			secret.Pid = 0
+			if secret.Secret == "" {
+				value, err := randomCredential()
+				if err != nil {
+					return fmt.Errorf("creating OpenID client %s: %w", client.ClientId, err)
+				}
+				secret.Secret = value
+				r.resetNeeded = append(r.resetNeeded, fmt.Sprintf("OpenID client %s/%s: client secret %d", client.ModuleId, client.ClientId, i+1))
+			}
+			secrets[i] = secret
+		}
+		client.ClientSecrets = secrets
+		if _, err := r.client.PostOpenIdClient(r.ctx, client); err != nil {
+			return fmt.Errorf("creating OpenID client %s: %w", client.ClientId, err)
+		}
+		r.restored++
+	}
+
+	for _, user := range n.inventory.Users {
+		user.Pid = 0
+		user.NodeId = n.nodeId
+		user.FailedLoginCount = 0
+		user.LockedAt = ""
+		user.LastActive = ""
+		user.LastConnected = ""
+		if user.Password == "" && !smilecdr.BoolValue(user.External) {
+			password, err := randomCredential()
+			if err != nil {
+				return fmt.Errorf("creating user %s: %w", user.Username, err)
+			}
+			user.Password = password
+			r.resetNeeded = append(r.resetNeeded, fmt.Sprintf("user %s/%s: password", user.ModuleId, user.Username))
+		}
+		if _, err := r.client.PostUser(r.ctx, user); err != nil {
+			return fmt.Errorf("creating user %s: %w", user.Username, err)
+		}
+		r.restored++
+	}
+
+	return nil
+}
+
+// orderModules sorts the modules so that each one comes after the modules it depends on. A dependency
+// that is not in the archive must already exist on the server.
+func orderModules(modules []smilecdr.ModuleConfig, existing map[string]bool) ([]smilecdr.ModuleConfig, error) {
+	byId := map[string]smilecdr.ModuleConfig{}
+	ids := make([]string, 0, len(modules))
+	for _, module := range modules {
+		byId[module.ModuleId] = module
+		ids = append(ids, module.ModuleId)
+	}
+	sort.Strings(ids)
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := map[string]int{}
+	var ordered []smilecdr.ModuleConfig
+
+	var visit func(id string, path []string) error
+	visit = func(id string, path []string) error {
+		switch state[id] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("module dependencies form a cycle: %s -> %s", strings.Join(path, " -> "), id)
+		}
+		state[id] = visiting
+
+		module := byId[id]
+		for _, dependency := range module.Dependencies {
+			if _, ok := byId[dependency.ModuleId]; !ok {
+				if !existing[dependency.ModuleId] {
+					return fmt.Errorf("module %s depends on %s, which is neither in the archive nor on the server", id, dependency.ModuleId)
+				}
+				continue
+			}
+			if err := visit(dependency.ModuleId, append(path, id)); err != nil {
+				return err
+			}
+		}
+
+		state[id] = done
+		ordered = append(ordered, module)
+		return nil
+	}
+
+	for _, id := range ids {
+		if err := visit(id, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// randomSource is where random credentials are read from.
+var randomSource io.Reader = rand.Reader
+
+// randomCredential returns a value nobody knows, meeting the usual password strength rules.
+func randomCredential() (string, error) {
+	b := make([]byte, 24)
+	if _, err := io.ReadFull(randomSource, b); err != nil {
+		return "", fmt.Errorf("generating a random credential: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b) + "aZ9!", nil
+}
@@ -0,0 +1,216 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr/smilecdrtest"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	source := newTestServer()
+	module := source.Modules["Master/smart_auth"]
+	module.SetOption("openid.signing.keystore_password", "keystore-password-value")
+	source.Modules["Master/smart_auth"] = module
+	sourceUrl := source.Start()
+	defer source.Close()
+
+	archive := filepath.Join(t.TempDir(), "snapshot.json")
+	var stdout bytes.Buffer
+	err := runSnapshot([]string{"-base-url", sourceUrl, "-username", "admin", "-password", "password", "-out", archive}, &stdout)
+	if err != nil {
+		t.Fatalf("snapshot failed: %s", err)
+	}
+
+	content, err := os.ReadFile(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"s3cr3t-value", "idp-secret-value", "Passw0rd-hash", "keystore-password-value"} {
+		if strings.Contains(string(content), secret) {
+			t.Errorf("the archive leaks the secret %q", secret)
+		}
+	}
+	inventory, err := smilecdr.ReadInventoryFile(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inventory.Modules) != 3 || len(inventory.OpenIdClients) != 1 || len(inventory.IdentityProviders) != 1 || len(inventory.Users) != 1 {
+		t.Fatalf("unexpected archive content: %s", content)
+	}
+
+	target := smilecdrtest.NewServer()
+	targetUrl := target.Start()
+	defer target.Close()
+
+	stdout.Reset()
+	err = runRestore([]string{"-base-url", targetUrl, "-username", "admin", "-password", "password", "-file", archive}, &stdout)
+	if err != nil {
+		t.Fatalf("restore failed: %s", err)
+	}
+
+	var posts []string
+	for _, request := range target.Requests {
+		if strings.HasPrefix(request, "POST ") {
+			posts = append(posts, request)
+		}
+	}
+	expected := []string{
+		"POST /module-config/Master/local_security/create",
+		"POST /module-config/Master/admin_json/create",
+		"POST /module-config/Master/smart_auth/create",
+		"POST /openid-connect-servers/Master/smart_auth",
+		"POST /openid-connect-clients/Master/smart_auth",
+		"POST /user-management/Master/local_security",
+	}
+	if strings.Join(posts, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected restore requests:\n%s", strings.Join(posts, "\n"))
+	}
+
+	if len(target.Modules) != 3 || target.Modules["Master/smart_auth"].Dependencies[0].ModuleId != "local_security" {
+		t.Errorf("modules not restored: %+v", target.Modules)
+	}
+	client := target.OpenIdClients[0]
+	if client.ClientId != "my-app" || len(client.ClientSecrets) != 1 || client.ClientSecrets[0].Secret == "" || client.ClientSecrets[0].Secret == "s3cr3t-value" {
+		t.Errorf("client not restored with a new secret: %+v", client)
+	}
+	user := target.Users[0]
+	if user.Username != "service-account" || user.Password == "" || user.Password == "Passw0rd-hash" || len(user.Authorities) != 1 {
+		t.Errorf("user not restored with a new password: %+v", user)
+	}
+	for _, reset := range []string{"OpenID client smart_auth/my-app: client secret 1", "user local_security/service-account: password", "identity provider smart_auth/Corporate IdP", "module smart_auth: option openid.signing.keystore_password"} {
+		if !strings.Contains(stdout.String(), reset) {
+			t.Errorf("restore output does not list %q:\n%s", reset, stdout.String())
+		}
+	}
+
+	// The target is no longer empty.
+	err = runRestore([]string{"-base-url", targetUrl, "-username", "admin", "-password", "password", "-file", archive}, &stdout)
+	if err == nil || !strings.Contains(err.Error(), "local_security, admin_json, smart_auth") {
+		t.Errorf("expected the restore to refuse existing modules, got %v", err)
+	}
+}
+
+func TestOrderModules(t *testing.T) {
+	modules := []smilecdr.ModuleConfig{
+		{ModuleId: "fhir_endpoint", Dependencies: []smilecdr.ModuleDependency{{ModuleId: "persistence", Type: "PERSISTENCE_ALL"}, {ModuleId: "smart_auth", Type: "SECURITY_OUT_SMART"}}},
+		{ModuleId: "smart_auth", Dependencies: []smilecdr.ModuleDependency{{ModuleId: "local_security", Type: "SECURITY_IN_UP"}}},
+		{ModuleId: "persistence"},
+	}
+
+	ordered, err := orderModules(modules, map[string]bool{"local_security": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, module := range ordered {
+		ids = append(ids, module.ModuleId)
+	}
+	if strings.Join(ids, ",") != "persistence,smart_auth,fhir_endpoint" {
+		t.Errorf("unexpected order %v", ids)
+	}
+
+	if _, err := orderModules(modules, map[string]bool{}); err == nil || !strings.Contains(err.Error(), "local_security") {
+		t.Errorf("expected a missing dependency error, got %v", err)
+	}
+
+	modules[2].Dependencies = []smilecdr.ModuleDependency{{ModuleId: "fhir_endpoint", Type: "ENDPOINT_FHIR"}}
+	if _, err := orderModules(modules, map[string]bool{"local_security": true}); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("expected a cycle error, got %v", err)
+	}
+}
+
+func TestRestoreRandomFailure(t *testing.T) {
+	source := newTestServer()
+	sourceUrl := source.Start()
+	defer source.Close()
+
+	archive := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := runSnapshot([]string{"-base-url", sourceUrl, "-username", "admin", "-password", "password", "-out", archive}, &bytes.Buffer{}); err != nil {
+		t.Fatalf("snapshot failed: %s", err)
+	}
+
+	defer func(source io.Reader) { randomSource = source }(randomSource)
+	randomSource = iotest.ErrReader(errors.New("entropy exhausted"))
+
+	target := smilecdrtest.NewServer()
+	targetUrl := target.Start()
+	defer target.Close()
+
+	err := runRestore([]string{"-base-url", targetUrl, "-username", "admin", "-password", "password", "-file", archive}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "creating OpenID client my-app: generating a random credential: entropy exhausted") {
+		t.Errorf("expected the restore to fail creating the client, got %v", err)
+	}
+	if len(target.OpenIdClients) != 0 {
+		t.Errorf("a client was created without a secret: %+v", target.OpenIdClients)
+	}
+}
+
+func TestSnapshotRestoreNodes(t *testing.T) {
+	source := newTestServer()
+	source.Modules["Node2/local_security"] = source.Modules["Master/local_security"]
+	user := source.Users[0]
+	user.Pid, user.NodeId, user.Username = 4, "Node2", "node2-account"
+	source.Users = append(source.Users, user)
+	sourceUrl := source.Start()
+	defer source.Close()
+
+	archive := filepath.Join(t.TempDir(), "snapshot.json")
+	err := runSnapshot([]string{"-base-url", sourceUrl, "-username", "admin", "-password", "password", "-out", archive, "-node-id", "Master,Node2"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("snapshot failed: %s", err)
+	}
+	inventory, err := smilecdr.ReadInventoryFile(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inventory.Nodes) != 2 || len(inventory.Nodes[1].Modules) != 1 || len(inventory.Nodes[1].Users) != 1 || inventory.Nodes[1].Users[0].Password != "" {
+		t.Fatalf("unexpected archive content: %+v", inventory)
+	}
+
+	target := smilecdrtest.NewServer()
+	target.NodeIds = []string{"Master", "Node2"}
+	targetUrl := target.Start()
+	defer target.Close()
+
+	err = runRestore([]string{"-base-url", targetUrl, "-username", "admin", "-password", "password", "-file", archive, "-node-id", "Master"}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "-node-id cannot be used with an archive of 2 nodes") {
+		t.Errorf("expected -node-id to be refused, got %v", err)
+	}
+
+	err = runRestore([]string{"-base-url", targetUrl, "-username", "admin", "-password", "password", "-file", archive}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("restore failed: %s", err)
+	}
+	if len(target.Modules) != 4 || target.Modules["Node2/local_security"].ModuleType != "SECURITY_IN_LOCAL" {
+		t.Errorf("modules not restored to their nodes: %+v", target.Modules)
+	}
+	if len(target.Users) != 2 || target.Users[1].NodeId != "Node2" || target.Users[1].Username != "node2-account" {
+		t.Errorf("users not restored to their nodes: %+v", target.Users)
+	}
+
+	// A conflict on any node is found before anything is created.
+	delete(target.Modules, "Master/admin_json")
+	delete(target.Modules, "Master/smart_auth")
+	delete(target.Modules, "Master/local_security")
+	requests := len(target.Requests)
+	err = runRestore([]string{"-base-url", targetUrl, "-username", "admin", "-password", "password", "-file", archive}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "modules already exist on node Node2: local_security") {
+		t.Errorf("expected the restore to refuse the existing module of Node2, got %v", err)
+	}
+	for _, request := range target.Requests[requests:] {
+		if strings.HasPrefix(request, "POST ") {
+			t.Errorf("the refused restore sent %s", request)
+		}
+	}
+}
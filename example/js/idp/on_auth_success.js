@@ -10,7 +10,7 @@
 
 println("on_auth_success.js");
 
-function onAuthenticationSuccess(theOutcome, theOutcomeFactory, theContext) {
+function onAuthenticateSuccess(theOutcome, theOutcomeFactory, theContext) {
     
     // This Identity Provider is for Patient Portal. It retrieves the patientId as HDID claim.
     var patientId = theContext.getClaim('hdid');
@@ -25,7 +25,7 @@ resource "smilecdr_openid_client" "client1" {
   } 
   fixed_scope                    = false
   refresh_token_validity_seconds = 86400
-  registered_redirect_uris       = ["http://example-client1.com:6000", "http://example-client1.com:6001"]
+  registered_redirect_uris       = ["https://example-client1.com:6000", "https://example-client1.com:6001"]
   scopes = [
     "openid",
     "profile",
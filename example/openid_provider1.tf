@@ -12,7 +12,7 @@ resource "smilecdr_openid_identity_provider" "example_idp1" {
   federation_token_url                = "http://localhost:8800/token"
   federation_user_info_url            = "http://localhost:8800/userinfo"
   federation_jwk_set_url              = "http://localhost:8800/auth/jwks"
-  federation_auth_script_text         = local.idp_auth_script
+  federation_auth_script_source       = "${path.module}/js/idp/on_auth_success.js"
   token_introspection_client_id       = "smile"
   token_introspection_client_secret   = "client_secret_goes_here"
 }
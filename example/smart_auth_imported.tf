@@ -6,7 +6,7 @@ resource "smilecdr_smart_outbound_security" "smart_auth" {
   node_id                              = "Master"
   http_listener_port                   = 9200
   oidc_issuer_url                      = "http://smilecdr:9200"
-  smart_authorization_scopes_supported = "launch/patient patient/*.read user/*.read offline_access openid profile email"
+  smart_authorization_scopes_supported = ["launch/patient", "patient/*.read", "user/*.read", "offline_access", "openid", "profile", "email"]
   cors_enabled                         = true
   oidc_federate_mode_enabled           = true
   oidc_smart_capabilities_list = [
@@ -14,7 +14,7 @@ resource "smilecdr_smart_inbound_security" "ex1_smart_inbound" {
   cache_authentication_seconds                    = 300
   key_validation_prevent_token_key_reuse          = false
   key_validation_require_key_expiry               = false
-  smart_configuration_scopes_supported            = "openid fhirUser patient/*.read"
+  smart_configuration_scopes_supported            = ["openid", "fhirUser", "patient/*.read"]
   token_endpoint                                  = "http://localhost:8080/auth/realms/poc/protocol/openid-connect/token"
   authorization_endpoint                          = "http://localhost:8080/auth/realms/poc/protocol/openid-connect/auth"
   management_endpoint                             = "http://localhost:8080/auth/realms/poc/account"
@@ -22,7 +22,7 @@ resource "smilecdr_smart_inbound_security" "ex1_smart_inbound" {
   revocation_endpoint                             = "http://localhost:8080/auth/realms/poc/protocol/openid-connect/revoke"
   introspection_client_jwks_cache_mins            = 10
   introspection_client_truststore_file            = "file://somefile.jks"
-  callback_script_text                            = local.inbound_callback_script
+  callback_script_source                          = "${path.module}/js/local_inbound_security/authentication_callback.js"
   tfa_totp_issuer_name                            = "issuerName"
   tfa_totp_lock_after_failed_attempts             = 2
   seed_servers_file                               = "seedServers.txt"
@@ -10,7 +10,7 @@ resource "smilecdr_smart_outbound_security" "smart_auth_federated" {
 		oidc_issuer_url                       = "http://keycloak:8080/auth/realms/poc"
 		oidc_federate_mode_enabled            = true
 		smart_authorization_enforce_approved_scopes = true
-		smart_authorization_scopes_supported        = "launch fhirUser openid profile patient/*.read"
+		smart_authorization_scopes_supported        = ["launch", "fhirUser", "openid", "profile", "patient/*.read"]
 		sessions_max_concurrent_sessions_per_user   = 3
 		dependency_fhir_persistence_module          = "PERSISTENCE_ALL"
 }
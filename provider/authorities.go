@@ -0,0 +1,101 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zedwerks/terraform-smilecdr/provider/helper/validations"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+)
+
+// userPermissionCatalog returns the permission names accepted for users and clients, and where they came from.
+// The server catalog is only used when the provider is configured for it, and the built-in catalog is
+// used whenever the server catalog cannot be loaded.
+func userPermissionCatalog(ctx context.Context, m interface{}) (map[string]bool, string) {
+	catalog := map[string]bool{}
+
+	if c, ok := m.(*smilecdr.Client); ok && c != nil && c.ServerPermissionCatalog {
+		permissions, err := c.CachedPermissions(ctx)
+		if err == nil && len(permissions) > 0 {
+			for _, permission := range permissions {
+				catalog[permission.Name] = true
+			}
+			return catalog, "server"
+		}
+		tflog.Warn(ctx, "Unable to load the permission catalog from the server, using the built-in catalog instead.")
+	}
+
+	for _, name := range validations.BuiltinUserPermissions() {
+		catalog[name] = true
+	}
+	return catalog, "builtin"
+}
+
+// rawConfigAuthorities returns the permission/argument pairs configured in the given block set.
+// Blocks that are not yet known at plan time are skipped, as they are validated again at apply time.
+func rawConfigAuthorities(rawConfig cty.Value, attribute string) []map[string]string {
+	var authorities []map[string]string
+
+	if rawConfig.IsNull() || !rawConfig.IsKnown() {
+		return authorities
+	}
+	blocks := rawConfig.GetAttr(attribute)
+	if blocks.IsNull() || !blocks.IsKnown() {
+		return authorities
+	}
+
+	for it := blocks.ElementIterator(); it.Next(); {
+		_, block := it.Element()
+		if block.IsNull() || !block.IsWhollyKnown() {
+			continue
+		}
+		authority := map[string]string{}
+		for _, name := range []string{"permission", "argument"} {
+			value := block.GetAttr(name)
+			if !value.IsNull() {
+				authority[name] = value.AsString()
+			}
+		}
+		authorities = append(authorities, authority)
+	}
+
+	return authorities
+}
+
+// validateAuthoritiesDiff checks every configured permission block against the permission catalog,
+// and its argument against the argument grammar of that permission.
+func validateAuthoritiesDiff(attribute string) schema.CustomizeDiffFunc {
+	return func(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+		var errs []string
+
+		authorities := rawConfigAuthorities(d.GetRawConfig(), attribute)
+		if len(authorities) == 0 {
+			return nil
+		}
+		catalog, source := userPermissionCatalog(ctx, m)
+
+		for _, authority := range authorities {
+			if !catalog[authority["permission"]] {
+				errs = append(errs, fmt.Sprintf("%s: invalid user permission %s, not found in the %s permission catalog", attribute, authority["permission"], source))
+				continue
+			}
+			err := validations.ValidateUserPermissionArgument(authority["permission"], authority["argument"])
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %s", attribute, err.Error()))
+			}
+		}
+
+		if len(errs) > 0 {
+			return errors.New(strings.Join(errs, "\n"))
+		}
+		return nil
+	}
+}
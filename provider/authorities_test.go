@@ -0,0 +1,68 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/zedwerks/terraform-smilecdr/provider/helper/validations"
+)
+
+func TestUserPermissionArguments(t *testing.T) {
+	valid := []struct {
+		permission string
+		argument   string
+	}{
+		{"FHIR_ALL_READ", ""},
+		{"ROLE_FHIR_CLIENT_SUPERUSER", ""},
+		{"FHIR_READ_ALL_IN_COMPARTMENT", "Patient/123"},
+		{"FHIR_WRITE_ALL_IN_COMPARTMENT", "Patient/123, Practitioner/abc-1"},
+		{"FHIR_READ_TYPE_IN_COMPARTMENT", "Observation:Patient/123"},
+		{"FHIR_READ_ALL_OF_TYPE", "Observation"},
+		{"FHIR_WRITE_ALL_OF_TYPE", "Observation,Condition"},
+		{"FHIR_READ_ALL_OF_TYPE", "Observation?category=laboratory"},
+		{"FHIR_READ_INSTANCE", "Patient/123"},
+		{"FHIR_ACCESS_PARTITION_NAME", "PARTITION-A,partition_b"},
+		{"BLOCK_FHIR_READ_UNLESS_CODE_IN_VS", "Observation?code:in=http://example.org/ValueSet/vs1"},
+		{"BLOCK_FHIR_READ_UNLESS_CODE_NOT_IN_VS", "Observation?code:in=urn:oid:2.16.840.1"},
+		{"FHIR_EXTENDED_OPERATION_ON_SERVER", "$reindex"},
+		{"FHIR_EXTENDED_OPERATION_ON_TYPE", "Patient/$everything"},
+		// Permissions without a known grammar accept any argument.
+		{"FHIR_OP_BINARY_ACCESS_READ", "anything"},
+	}
+	for _, p := range valid {
+		if err := validations.ValidateUserPermissionArgument(p.permission, p.argument); err != nil {
+			t.Errorf("%s %q is rejected: %s", p.permission, p.argument, err)
+		}
+	}
+
+	invalid := []struct {
+		permission string
+		argument   string
+		err        string
+	}{
+		{"FHIR_READ_ALL_IN_COMPARTMENT", "", "requires an argument of the form {{compartmentType}}/{{id}}"},
+		{"FHIR_ACCESS_PARTITION_NAME", "", "requires an argument of the form a comma-separated list of partition names"},
+		{"FHIR_ALL_READ", "Patient/123", "does not take an argument, got 'Patient/123'"},
+		{"ROLE_FHIR_CLIENT", "x", "does not take an argument, got 'x'"},
+		{"FHIR_READ_ALL_IN_COMPARTMENT", "Observation/123", "'Observation' is not a FHIR compartment type"},
+		{"FHIR_READ_ALL_IN_COMPARTMENT", "Patient", "'Patient' is not a resource reference"},
+		{"FHIR_WRITE_ALL_IN_COMPARTMENT", "Patient/123,Patient/a b", "'a b' is not a valid resource id"},
+		{"FHIR_READ_TYPE_IN_COMPARTMENT", "Observation/Patient/123", "missing ':' between resource type and compartment"},
+		{"FHIR_READ_ALL_OF_TYPE", "Observation,Observations", "'Observations' is not a FHIR R4 resource type"},
+		{"FHIR_READ_ALL_OF_TYPE", "Observation?", "empty search parameters after '?'"},
+		{"FHIR_ACCESS_PARTITION_NAME", "PARTITION A", "'PARTITION A' is not a valid partition name"},
+		{"BLOCK_FHIR_READ_UNLESS_CODE_IN_VS", "Observation", "missing '?' after resource type"},
+		{"BLOCK_FHIR_READ_UNLESS_CODE_IN_VS", "Observation?code=http://example.org/ValueSet/vs1", "missing ':in=' after search parameter"},
+		{"BLOCK_FHIR_READ_UNLESS_CODE_IN_VS", "Observation?code:in=ValueSet/vs1", "'ValueSet/vs1' is not an absolute ValueSet URL"},
+		{"FHIR_EXTENDED_OPERATION_ON_TYPE", "$everything", "missing '/' between resource type and operation"},
+	}
+	for _, p := range invalid {
+		err := validations.ValidateUserPermissionArgument(p.permission, p.argument)
+		if err == nil || !strings.Contains(err.Error(), p.err) {
+			t.Errorf("%s %q: expected an error containing %q, got %v", p.permission, p.argument, p.err, err)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// customizeDiffAll runs every function, like customdiff.All, but returns a single error as is instead of
+// joining it, so that an error reported on an attribute keeps its attribute path.
+func customizeDiffAll(funcs ...schema.CustomizeDiffFunc) schema.CustomizeDiffFunc {
+	return func(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+		var errs []error
+		for _, f := range funcs {
+			if err := f(ctx, d, m); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if len(errs) == 1 {
+			return errs[0]
+		}
+		return errors.Join(errs...)
+	}
+}
+
+// attributeError is an error found at plan time in the value of an attribute.
+type attributeError struct {
+	attribute string
+	path      cty.Path
+	message   string
+}
+
+// attributeErrors returns a single error as a cty.PathError, which Terraform reports on its attribute.
+// Several errors are joined, each prefixed with its attribute.
+func attributeErrors(errs []attributeError) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0].path.NewErrorf("%s", errs[0].message)
+	}
+
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.attribute + ": " + err.message
+	}
+	return errors.New(strings.Join(messages, "\n"))
+}
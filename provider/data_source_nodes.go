@@ -0,0 +1,99 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+)
+
+func dataSourceNodes() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceNodesRead,
+		Schema: map[string]*schema.Schema{
+			"node_ids": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The IDs of every node of the cluster, in alphabetical order.",
+			},
+			"nodes": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"node_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"modules": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"module_id": {
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+									"module_type": {
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func flattenNodes(nodes []smilecdr.Node) ([]interface{}, []interface{}) {
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].NodeId < nodes[j].NodeId })
+
+	nodeIds := make([]interface{}, len(nodes))
+	flattened := make([]interface{}, len(nodes))
+
+	for i, node := range nodes {
+		sort.Slice(node.Modules, func(a, b int) bool { return node.Modules[a].ModuleId < node.Modules[b].ModuleId })
+
+		modules := make([]interface{}, len(node.Modules))
+		for j, module := range node.Modules {
+			modules[j] = map[string]interface{}{
+				"module_id":   module.ModuleId,
+				"module_type": module.ModuleType,
+			}
+		}
+		nodeIds[i] = node.NodeId
+		flattened[i] = map[string]interface{}{
+			"node_id": node.NodeId,
+			"modules": modules,
+		}
+	}
+
+	return nodeIds, flattened
+}
+
+func dataSourceNodesRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c := m.(*smilecdr.Client)
+
+	nodes, err := c.GetNodes(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	nodeIds, flattened := flattenNodes(nodes)
+
+	d.SetId("nodes")
+	d.Set("node_ids", nodeIds)
+	d.Set("nodes", flattened)
+
+	return nil
+}
@@ -0,0 +1,169 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr/smilecdrtest"
+)
+
+func TestNodesDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testNodesDataSourceConfig(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckTypeSetElemAttr("data.smilecdr_nodes.cluster", "node_ids.*", "Master"),
+					resource.TestCheckResourceAttrSet("data.smilecdr_nodes.cluster", "nodes.0.modules.#"),
+				),
+			},
+		},
+	})
+}
+
+func testNodesDataSourceConfig() string {
+	return `data "smilecdr_nodes" "cluster" {}`
+}
+
+func TestModuleConfigOnNodes(t *testing.T) {
+	moduleName := "smart_" + acctest.RandString(8)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testModuleConfigOnNodesConfig(moduleName),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("smilecdr_module_config.testacc", "node_id", "data.smilecdr_nodes.cluster", "node_ids.0"),
+					resource.TestCheckResourceAttrPair("smilecdr_module_config.testacc", "node_ids.#", "data.smilecdr_nodes.cluster", "node_ids.#"),
+				),
+			},
+		},
+	})
+}
+
+func testModuleConfigOnNodesConfig(moduleName string) string {
+
+	return fmt.Sprintf(`data "smilecdr_nodes" "cluster" {}
+
+	resource "smilecdr_module_config" "testacc" {
+		module_id   = "%s"
+		module_type = "SECURITY_OUT_SMART"
+		node_ids    = data.smilecdr_nodes.cluster.node_ids
+
+		options {
+			key   = "issuer.url"
+			value = "http://localhost:9200"
+		}
+
+		dependencies {
+			module_id = "local_security"
+			type      = "SECURITY_IN_UP"
+		}
+	}`, moduleName)
+}
+
+func TestModuleConfigNodes(t *testing.T) {
+	server := smilecdrtest.NewServer()
+	c := smilecdr.NewClient(context.Background(), server.Start(), "admin", "password")
+	defer server.Close()
+
+	server.NodeIds = []string{"Master", "Node2", "Node3"}
+	for _, nodeId := range server.NodeIds {
+		server.Modules[nodeId+"/local_security"] = smilecdr.ModuleConfig{ModuleId: "local_security", ModuleType: "SECURITY_IN_LOCAL"}
+	}
+
+	nodes := dataSourceNodes().TestResourceData()
+	if diags := dataSourceNodesRead(context.Background(), nodes, c); diags.HasError() {
+		t.Fatalf("reading the nodes failed: %+v", diags)
+	}
+	if ids := nodes.Get("node_ids").([]interface{}); len(ids) != 3 || nodes.Get("nodes.1.modules.0.module_id") != "local_security" {
+		t.Errorf("the nodes are read as %v", nodes.Get("nodes"))
+	}
+
+	r := resourceModuleConfig()
+	config := map[string]interface{}{
+		"module_id":    "smart_auth",
+		"module_type":  "SECURITY_OUT_SMART",
+		"node_ids":     []interface{}{"Node2", "Master"},
+		"options":      []interface{}{map[string]interface{}{"key": "issuer.url", "value": "https://smart.example.org"}},
+		"dependencies": []interface{}{map[string]interface{}{"module_id": "local_security", "type": "SECURITY_IN_UP"}},
+	}
+	issuer := func(nodeId string) string {
+		module, ok := server.Modules[nodeId+"/smart_auth"]
+		if !ok {
+			return ""
+		}
+		value, _ := module.LookupOptionOk("issuer.url")
+		return value
+	}
+	stateNodeIds := func(state *terraform.InstanceState) []string {
+		var ids []string
+		for key, value := range state.Attributes {
+			if strings.HasPrefix(key, "node_ids.") && key != "node_ids.#" {
+				ids = append(ids, value)
+			}
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	state := testApply(t, r, testRawConfigState(r, config), config, c)
+	if issuer("Master") == "" || issuer("Node2") == "" || issuer("Node3") != "" {
+		t.Errorf("created on the wrong nodes: %v", server.Modules)
+	}
+	if state.Attributes["node_id"] != "Master" || !reflect.DeepEqual(stateNodeIds(state), []string{"Master", "Node2"}) {
+		t.Errorf("created with node_id %s and node_ids %v", state.Attributes["node_id"], stateNodeIds(state))
+	}
+
+	config["options"] = []interface{}{map[string]interface{}{"key": "issuer.url", "value": "https://auth.example.org"}}
+	state = testApply(t, r, state, config, c)
+	if issuer("Master") != "https://auth.example.org" || issuer("Node2") != "https://auth.example.org" {
+		t.Errorf("updated to %q on Master and %q on Node2", issuer("Master"), issuer("Node2"))
+	}
+
+	// A node holding a different configuration is dropped from the state, and updated again by the next apply.
+	module := server.Modules["Node2/smart_auth"]
+	module.Options = []smilecdr.ModuleOption{{Key: "issuer.url", Value: "https://diverged.example.org"}}
+	server.Modules["Node2/smart_auth"] = module
+	state, diags := r.RefreshWithoutUpgrade(context.Background(), state, c)
+	if diags.HasError() {
+		t.Fatalf("refresh failed: %+v", diags)
+	}
+	if !reflect.DeepEqual(stateNodeIds(state), []string{"Master"}) {
+		t.Errorf("the divergent node is kept in node_ids %v", stateNodeIds(state))
+	}
+	state = testApply(t, r, state, config, c)
+	if issuer("Node2") != "https://auth.example.org" || !reflect.DeepEqual(stateNodeIds(state), []string{"Master", "Node2"}) {
+		t.Errorf("the divergent node is not updated: %q, node_ids %v", issuer("Node2"), stateNodeIds(state))
+	}
+
+	// Moving to other nodes creates the module on the new ones, and archives it from the others.
+	config["node_ids"] = []interface{}{"Master", "Node3"}
+	state = testApply(t, r, state, config, c)
+	if issuer("Node3") != "https://auth.example.org" || issuer("Node2") != "" {
+		t.Errorf("moved to the wrong nodes: %v", server.Modules)
+	}
+
+	// Dependencies are checked on every node.
+	server.Modules["Node3/local_security"] = smilecdr.ModuleConfig{ModuleId: "local_security", ModuleType: "ADMIN_JSON"}
+	config["module_id"] = "smart_auth_2"
+	_, err := r.Diff(context.Background(), testRawConfigState(r, config), terraform.NewResourceConfigRaw(config), c)
+	if err == nil || !strings.Contains(err.Error(), "module local_security is a ADMIN_JSON module on node Node3") {
+		t.Errorf("a dependency of the wrong type on another node plans with %v", err)
+	}
+}
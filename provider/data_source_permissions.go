@@ -0,0 +1,99 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zedwerks/terraform-smilecdr/provider/helper/validations"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+)
+
+func dataSourcePermissions() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourcePermissionsRead,
+		Schema: map[string]*schema.Schema{
+			"source": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Where the permissions were read from: 'server' when read from the connected Smile CDR server, or 'builtin' when the server catalog was unavailable.",
+			},
+			"names": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The names of all valid permissions.",
+			},
+			"permissions": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"description": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"argument_required": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func flattenPermissionDefinitions(definitions []smilecdr.PermissionDefinition) ([]interface{}, []interface{}) {
+	names := make([]interface{}, len(definitions))
+	perms := make([]interface{}, len(definitions))
+
+	for i, p := range definitions {
+		names[i] = p.Name
+		perms[i] = map[string]interface{}{
+			"name":              p.Name,
+			"description":       p.Description,
+			"argument_required": p.ArgumentRequired,
+		}
+	}
+
+	return names, perms
+}
+
+func dataSourcePermissionsRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	c := m.(*smilecdr.Client)
+
+	source := "server"
+	definitions, err := c.CachedPermissions(ctx)
+
+	if err != nil || len(definitions) == 0 {
+		source = "builtin"
+		definitions = []smilecdr.PermissionDefinition{}
+		for _, name := range validations.BuiltinUserPermissions() {
+			definitions = append(definitions, smilecdr.PermissionDefinition{Name: name})
+		}
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Unable to read the permission catalog from the server, using the built-in catalog instead",
+		})
+	}
+
+	names, perms := flattenPermissionDefinitions(definitions)
+
+	d.SetId(source)
+	d.Set("source", source)
+	d.Set("names", names)
+	d.Set("permissions", perms)
+
+	return diags
+}
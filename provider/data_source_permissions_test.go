@@ -0,0 +1,88 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zedwerks/terraform-smilecdr/provider/helper/validations"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr/smilecdrtest"
+)
+
+func TestPermissionsDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testPermissionsDataSourceConfig(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("data.smilecdr_permissions.all", "source"),
+					resource.TestCheckResourceAttrSet("data.smilecdr_permissions.all", "names.#"),
+				),
+			},
+		},
+	})
+}
+
+func testPermissionsDataSourceConfig() string {
+	return `data "smilecdr_permissions" "all" {}`
+}
+
+func TestPermissionsDataSourceCatalog(t *testing.T) {
+	server := smilecdrtest.NewServer()
+	baseUrl := server.Start()
+	defer server.Close()
+
+	read := func() (*schema.ResourceData, diag.Diagnostics) {
+		c := smilecdr.NewClient(context.Background(), baseUrl, "admin", "password")
+		d := dataSourcePermissions().TestResourceData()
+		return d, dataSourcePermissionsRead(context.Background(), d, c)
+	}
+
+	// Without a catalog on the server, the built-in catalog is read, with a warning.
+	d, diags := read()
+	if d.Get("source") != "builtin" || len(diags) != 1 || diags[0].Severity != diag.Warning {
+		t.Errorf("without a server catalog, read %q with %+v", d.Get("source"), diags)
+	}
+	if len(d.Get("names").([]interface{})) != len(validations.BuiltinUserPermissions()) {
+		t.Errorf("the built-in catalog read %d names", len(d.Get("names").([]interface{})))
+	}
+
+	server.Permissions = []smilecdr.PermissionDefinition{
+		{Name: "FHIR_ALL_READ", Description: "Read all FHIR resources"},
+		{Name: "FHIR_READ_ALL_IN_COMPARTMENT", ArgumentRequired: true},
+	}
+	d, diags = read()
+	if d.Get("source") != "server" || len(diags) != 0 {
+		t.Errorf("with a server catalog, read %q with %+v", d.Get("source"), diags)
+	}
+	if names := d.Get("names").([]interface{}); len(names) != 2 || names[0] != "FHIR_ALL_READ" {
+		t.Errorf("the server catalog read %v", names)
+	}
+	if d.Get("permissions.1.argument_required") != true {
+		t.Error("argument_required of the server catalog is not read")
+	}
+
+	// Resources only use the server catalog when configured to, and fall back to the built-in one.
+	c := smilecdr.NewClient(context.Background(), baseUrl, "admin", "password")
+	if _, source := userPermissionCatalog(context.Background(), c); source != "builtin" {
+		t.Errorf("the %s catalog is used without server_permission_catalog", source)
+	}
+	c.ServerPermissionCatalog = true
+	if catalog, source := userPermissionCatalog(context.Background(), c); source != "server" || len(catalog) != 2 {
+		t.Errorf("the %s catalog of %d permissions is used with server_permission_catalog", source, len(catalog))
+	}
+	server.Permissions = nil
+	c = smilecdr.NewClient(context.Background(), baseUrl, "admin", "password")
+	c.ServerPermissionCatalog = true
+	if _, source := userPermissionCatalog(context.Background(), c); source != "builtin" {
+		t.Errorf("the %s catalog is used while the server has none", source)
+	}
+}
@@ -2,7 +2,10 @@ package validations
 
 import (
 	"fmt"
+	"net"
+	"net/url"
 	"regexp"
+	"strings"
 
 	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
@@ -31,3 +34,53 @@ func IsValidClientID(i interface{}, k cty.Path) diag.Diagnostics {
 	}
 	return nil
 }
+
+// ValidateRedirectURI checks that a redirect URI of an OpenID client is an absolute https URL without a
+// fragment. Plain http is only allowed for the loopback interface, used by native and development apps.
+func ValidateRedirectURI(uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return fmt.Errorf("'%s' is not a URL: %s", uri, err)
+	}
+	if u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return fmt.Errorf("'%s' is not an absolute http or https URL", uri)
+	}
+	if u.Fragment != "" || strings.Contains(uri, "#") {
+		return fmt.Errorf("'%s' has a fragment, which a redirect URI cannot have", uri)
+	}
+	if u.Scheme == "http" && !isLoopbackHost(u.Hostname()) {
+		return fmt.Errorf("'%s' uses http, which is only allowed for localhost and loopback addresses; use https", uri)
+	}
+	return nil
+}
+
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func IsRedirectURI(i interface{}, k cty.Path) diag.Diagnostics {
+	value, ok := i.(string)
+	if !ok {
+		return diag.Diagnostics{
+			diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Invalid type for the field",
+				Detail:   fmt.Sprintf("Expected a string, but got %T", i),
+			},
+		}
+	}
+	if err := ValidateRedirectURI(value); err != nil {
+		return diag.Diagnostics{
+			diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Invalid redirect URI",
+				Detail:   err.Error(),
+			},
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package validations
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// PasswordPolicy holds the password strength rules of a local inbound security module.
+// A zero value disables the matching rule.
+type PasswordPolicy struct {
+	MinLength    int
+	MinLowercase int
+	MinUppercase int
+	MinDigits    int
+	MinSpecial   int
+}
+
+// PasswordPolicyViolations returns one message for every rule of the policy that the password breaks.
+func PasswordPolicyViolations(policy PasswordPolicy, password string) []string {
+	var violations []string
+	var length, lower, upper, digits, special int
+
+	for _, r := range password {
+		length++
+		switch {
+		case unicode.IsLower(r):
+			lower++
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsDigit(r):
+			digits++
+		case !unicode.IsSpace(r):
+			special++
+		}
+	}
+
+	check := func(count int, min int, what string) {
+		if count < min {
+			violations = append(violations, fmt.Sprintf("must contain at least %d %s, found %d", min, what, count))
+		}
+	}
+	check(length, policy.MinLength, "characters")
+	check(lower, policy.MinLowercase, "lowercase letters")
+	check(upper, policy.MinUppercase, "uppercase letters")
+	check(digits, policy.MinDigits, "digits")
+	check(special, policy.MinSpecial, "special characters")
+
+	return violations
+}
+
+// FormatPasswordPolicyViolations joins the violations into a single error message for the given attribute.
+func FormatPasswordPolicyViolations(attribute string, moduleId string, violations []string) error {
+	return fmt.Errorf("%s does not meet the password policy of module %s:\n  - %s", attribute, moduleId, strings.Join(violations, "\n  - "))
+}
@@ -0,0 +1,243 @@
+package validations
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// permissionArgumentRule describes the argument grammar of a single Smile CDR permission.
+type permissionArgumentRule struct {
+	required  bool
+	forbidden bool
+	format    string
+	validate  func(string) error
+}
+
+var (
+	fhirIdPattern        = regexp.MustCompile(`^[A-Za-z0-9\-\.]{1,64}$`)
+	partitionNamePattern = regexp.MustCompile(`^[A-Za-z0-9_\-\.]{1,200}$`)
+	searchParamPattern   = regexp.MustCompile(`^[a-z_][A-Za-z0-9_\-\.]*$`)
+	operationNamePattern = regexp.MustCompile(`^\$?[A-Za-z][A-Za-z0-9_\-\.]*$`)
+
+	noArgument = permissionArgumentRule{forbidden: true}
+
+	compartmentArgument = permissionArgumentRule{
+		required: true,
+		format:   "{{compartmentType}}/{{id}}, e.g. Patient/123",
+		validate: commaSeparated(validateCompartmentOwner),
+	}
+	typeInCompartmentArgument = permissionArgumentRule{
+		required: true,
+		format:   "{{resourceType}}:{{compartmentType}}/{{id}}, e.g. Observation:Patient/123",
+		validate: validateTypeInCompartment,
+	}
+	resourceTypeArgument = permissionArgumentRule{
+		required: true,
+		format:   "a comma-separated list of resource types, or {{resourceType}}?{{searchParameters}}, e.g. Observation",
+		validate: validateResourceTypes,
+	}
+	instanceArgument = permissionArgumentRule{
+		required: true,
+		format:   "{{resourceType}}/{{id}}, e.g. Patient/123",
+		validate: commaSeparated(validateResourceInstance),
+	}
+	partitionArgument = permissionArgumentRule{
+		required: true,
+		format:   "a comma-separated list of partition names, e.g. PARTITION-A",
+		validate: commaSeparated(validatePartitionName),
+	}
+	valueSetArgument = permissionArgumentRule{
+		required: true,
+		format:   "{{resourceType}}?{{searchParameter}}:in={{valueSetUrl}}, e.g. Observation?code:in=http://example.org/ValueSet/vs1",
+		validate: validateValueSetRestriction,
+	}
+	serverOperationArgument = permissionArgumentRule{
+		required: true,
+		format:   "{{operation}}, e.g. $reindex",
+		validate: validateOperationName,
+	}
+	typeOperationArgument = permissionArgumentRule{
+		required: true,
+		format:   "{{resourceType}}/{{operation}}, e.g. Patient/$everything",
+		validate: validateTypeOperation,
+	}
+
+	smileCdrPermissionArgumentRules = map[string]permissionArgumentRule{
+		"ACCESS_ADMIN_JSON":                               noArgument,
+		"ACCESS_ADMIN_WEB":                                noArgument,
+		"ACCESS_FHIRWEB":                                  noArgument,
+		"BLOCK_FHIR_READ_UNLESS_CODE_IN_VS":               valueSetArgument,
+		"BLOCK_FHIR_READ_UNLESS_CODE_NOT_IN_VS":           valueSetArgument,
+		"CHANGE_OWN_PASSWORD":                             noArgument,
+		"CHANGE_OWN_TFA_KEY":                              noArgument,
+		"FHIR_ACCESS_PARTITION_ALL":                       noArgument,
+		"FHIR_ACCESS_PARTITION_NAME":                      partitionArgument,
+		"FHIR_ALL_DELETE":                                 noArgument,
+		"FHIR_ALL_READ":                                   noArgument,
+		"FHIR_ALL_WRITE":                                  noArgument,
+		"FHIR_BATCH":                                      noArgument,
+		"FHIR_CAPABILITIES":                               noArgument,
+		"FHIR_DELETE_ALL_IN_COMPARTMENT":                  compartmentArgument,
+		"FHIR_DELETE_ALL_OF_TYPE":                         resourceTypeArgument,
+		"FHIR_DELETE_TYPE_IN_COMPARTMENT":                 typeInCompartmentArgument,
+		"FHIR_EXTENDED_OPERATION_ON_ANY_INSTANCE":         serverOperationArgument,
+		"FHIR_EXTENDED_OPERATION_ON_ANY_INSTANCE_OF_TYPE": typeOperationArgument,
+		"FHIR_EXTENDED_OPERATION_ON_SERVER":               serverOperationArgument,
+		"FHIR_EXTENDED_OPERATION_ON_TYPE":                 typeOperationArgument,
+		"FHIR_GRAPHQL":                                    noArgument,
+		"FHIR_PATCH":                                      noArgument,
+		"FHIR_READ_ALL_IN_COMPARTMENT":                    compartmentArgument,
+		"FHIR_READ_ALL_OF_TYPE":                           resourceTypeArgument,
+		"FHIR_READ_INSTANCE":                              instanceArgument,
+		"FHIR_READ_TYPE_IN_COMPARTMENT":                   typeInCompartmentArgument,
+		"FHIR_TRANSACTION":                                noArgument,
+		"FHIR_WRITE_ALL_IN_COMPARTMENT":                   compartmentArgument,
+		"FHIR_WRITE_ALL_OF_TYPE":                          resourceTypeArgument,
+		"FHIR_WRITE_INSTANCE":                             instanceArgument,
+		"FHIR_WRITE_TYPE_IN_COMPARTMENT":                  typeInCompartmentArgument,
+	}
+)
+
+// ValidateUserPermissionArgument checks the argument of a user or client permission against the
+// grammar expected by Smile CDR for that permission. Permissions without a known grammar accept any argument.
+func ValidateUserPermissionArgument(permission string, argument string) error {
+	rule, ok := smileCdrPermissionArgumentRules[permission]
+	if !ok && strings.HasPrefix(permission, "ROLE_") {
+		rule, ok = noArgument, true
+	}
+	if !ok {
+		return nil
+	}
+
+	if argument == "" {
+		if rule.required {
+			return fmt.Errorf("permission %s requires an argument of the form %s", permission, rule.format)
+		}
+		return nil
+	}
+
+	if rule.forbidden {
+		return fmt.Errorf("permission %s does not take an argument, got '%s'", permission, argument)
+	}
+
+	if rule.validate != nil {
+		if err := rule.validate(argument); err != nil {
+			return fmt.Errorf("invalid argument '%s' for permission %s: %s. Expected %s", argument, permission, err, rule.format)
+		}
+	}
+
+	return nil
+}
+
+func commaSeparated(validate func(string) error) func(string) error {
+	return func(argument string) error {
+		for _, part := range strings.Split(argument, ",") {
+			if err := validate(strings.TrimSpace(part)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func validateResourceType(resourceType string) error {
+	if !IsFhirResourceType(resourceType) {
+		return fmt.Errorf("'%s' is not a FHIR R4 resource type", resourceType)
+	}
+	return nil
+}
+
+func validateResourceInstance(reference string) error {
+	parts := strings.Split(reference, "/")
+	if len(parts) != 2 {
+		return fmt.Errorf("'%s' is not a resource reference", reference)
+	}
+	if err := validateResourceType(parts[0]); err != nil {
+		return err
+	}
+	if !fhirIdPattern.MatchString(parts[1]) {
+		return fmt.Errorf("'%s' is not a valid resource id", parts[1])
+	}
+	return nil
+}
+
+func validateCompartmentOwner(reference string) error {
+	if err := validateResourceInstance(reference); err != nil {
+		return err
+	}
+	compartmentType := strings.Split(reference, "/")[0]
+	if !fhirR4CompartmentTypes[compartmentType] {
+		return fmt.Errorf("'%s' is not a FHIR compartment type", compartmentType)
+	}
+	return nil
+}
+
+func validateTypeInCompartment(argument string) error {
+	parts := strings.SplitN(argument, ":", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("missing ':' between resource type and compartment")
+	}
+	if err := validateResourceType(parts[0]); err != nil {
+		return err
+	}
+	return validateCompartmentOwner(parts[1])
+}
+
+func validateResourceTypes(argument string) error {
+	if resourceType, query, found := strings.Cut(argument, "?"); found {
+		if query == "" {
+			return fmt.Errorf("empty search parameters after '?'")
+		}
+		return validateResourceType(resourceType)
+	}
+	return commaSeparated(validateResourceType)(argument)
+}
+
+func validatePartitionName(name string) error {
+	if !partitionNamePattern.MatchString(name) {
+		return fmt.Errorf("'%s' is not a valid partition name", name)
+	}
+	return nil
+}
+
+func validateValueSetRestriction(argument string) error {
+	resourceType, restriction, found := strings.Cut(argument, "?")
+	if !found {
+		return fmt.Errorf("missing '?' after resource type")
+	}
+	if err := validateResourceType(resourceType); err != nil {
+		return err
+	}
+	searchParam, valueSetUrl, found := strings.Cut(restriction, ":in=")
+	if !found {
+		return fmt.Errorf("missing ':in=' after search parameter")
+	}
+	if !searchParamPattern.MatchString(searchParam) {
+		return fmt.Errorf("'%s' is not a valid search parameter name", searchParam)
+	}
+	u, err := url.Parse(valueSetUrl)
+	if err != nil || u.Scheme == "" || (u.Host == "" && u.Scheme != "urn") {
+		return fmt.Errorf("'%s' is not an absolute ValueSet URL", valueSetUrl)
+	}
+	return nil
+}
+
+func validateOperationName(operation string) error {
+	if !operationNamePattern.MatchString(operation) {
+		return fmt.Errorf("'%s' is not a valid operation name", operation)
+	}
+	return nil
+}
+
+func validateTypeOperation(argument string) error {
+	resourceType, operation, found := strings.Cut(argument, "/")
+	if !found {
+		return fmt.Errorf("missing '/' between resource type and operation")
+	}
+	if err := validateResourceType(resourceType); err != nil {
+		return err
+	}
+	return validateOperationName(operation)
+}
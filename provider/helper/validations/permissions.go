@@ -2,6 +2,7 @@ package validations
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
@@ -158,6 +159,20 @@ var (
 	}
 )
 
+// BuiltinUserPermissions returns the sorted names of the permissions known to the provider.
+func BuiltinUserPermissions() []string {
+	names := make([]string, 0, len(smileCdrUserPermissionTypes))
+	for name := range smileCdrUserPermissionTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func IsBuiltinUserPermission(name string) bool {
+	return smileCdrUserPermissionTypes[name]
+}
+
 func IsUserPermission(i interface{}, k cty.Path) diag.Diagnostics {
 
 	// Convert the input value to a string (assuming the input is a string)
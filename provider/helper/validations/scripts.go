@@ -0,0 +1,144 @@
+package validations
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dop251/goja/ast"
+	"github.com/dop251/goja/parser"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// ScriptHook is a point where Smile CDR runs a callback script.
+type ScriptHook struct {
+	Summary   string
+	Functions []string // the callbacks Smile CDR calls, at least one of which the script must declare; none when it runs the script as a whole
+}
+
+// ScriptHooks are the callback hooks of Smile CDR by name, for the validation of scripts at plan time and
+// the script command.
+var ScriptHooks = map[string]ScriptHook{
+	"post-authorize":       {Summary: "SMART post-authorize callback", Functions: []string{"onSmartLoginPreContextSelection", "onTokenGenerating", "onPostAuthorize"}},
+	"authenticate-success": {Summary: "authentication callback of inbound security and identity providers", Functions: []string{"onAuthenticateSuccess"}},
+	"user-mapping":         {Summary: "federation user mapping", Functions: []string{"getUserName"}},
+	"codap-authorization":  {Summary: "CODAP authorization"},
+}
+
+// ScriptError is a syntax error of a callback script.
+type ScriptError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e ScriptError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// ParseScript parses a callback script as ECMAScript, without running it, and returns the names of the
+// functions it declares at its top level, or its syntax errors.
+func ParseScript(script string) ([]string, []ScriptError) {
+	program, err := parser.ParseFile(nil, "", script, 0)
+	if err != nil {
+		var errs []ScriptError
+		switch err := err.(type) {
+		case parser.ErrorList:
+			for _, e := range err {
+				errs = append(errs, ScriptError{Line: e.Position.Line, Column: e.Position.Column, Message: e.Message})
+			}
+		case *parser.Error:
+			errs = append(errs, ScriptError{Line: err.Position.Line, Column: err.Position.Column, Message: err.Message})
+		default:
+			errs = append(errs, ScriptError{Message: err.Error()})
+		}
+		return nil, errs
+	}
+
+	var functions []string
+	for _, statement := range program.Body {
+		switch statement := statement.(type) {
+		case *ast.FunctionDeclaration:
+			if statement.Function.Name != nil {
+				functions = append(functions, statement.Function.Name.Name.String())
+			}
+		case *ast.VariableStatement:
+			functions = append(functions, functionBindings(statement.List)...)
+		case *ast.LexicalDeclaration:
+			functions = append(functions, functionBindings(statement.List)...)
+		}
+	}
+	return functions, nil
+}
+
+// functionBindings returns the names of the variables bound to functions, as in var onPostAuthorize = function(...) {...}.
+func functionBindings(bindings []*ast.Binding) []string {
+	var functions []string
+	for _, binding := range bindings {
+		name, ok := binding.Target.(*ast.Identifier)
+		if !ok {
+			continue
+		}
+		switch binding.Initializer.(type) {
+		case *ast.FunctionLiteral, *ast.ArrowFunctionLiteral:
+			functions = append(functions, name.Name.String())
+		}
+	}
+	return functions
+}
+
+// IsScript validates the syntax of a callback script, named by its description in the diagnostics,
+// reporting each syntax error with its line and column. It warns when the script declares none of the
+// functions its hook, one of ScriptHooks, calls.
+func IsScript(description string, hook string) schema.SchemaValidateDiagFunc {
+	functions := ScriptHooks[hook].Functions
+	return func(i interface{}, k cty.Path) diag.Diagnostics {
+		script, ok := i.(string)
+		if !ok {
+			return diag.Diagnostics{
+				diag.Diagnostic{
+					Severity: diag.Error,
+					Summary:  "Invalid type for the field",
+					Detail:   fmt.Sprintf("Expected a string, but got %T", i),
+				},
+			}
+		}
+		if strings.TrimSpace(script) == "" {
+			return nil
+		}
+
+		declared, errs := ParseScript(script)
+		if len(errs) > 0 {
+			var diags diag.Diagnostics
+			for _, err := range errs {
+				diags = append(diags, diag.Diagnostic{
+					Severity:      diag.Error,
+					Summary:       "Invalid script",
+					Detail:        fmt.Sprintf("Syntax error in the %s script, %s", description, err.Error()),
+					AttributePath: k,
+				})
+			}
+			return diags
+		}
+
+		if len(functions) == 0 {
+			return nil
+		}
+		for _, function := range declared {
+			for _, expected := range functions {
+				if function == expected {
+					return nil
+				}
+			}
+		}
+		return diag.Diagnostics{
+			diag.Diagnostic{
+				Severity:      diag.Warning,
+				Summary:       "Missing callback function",
+				Detail:        fmt.Sprintf("The %s script declares none of the functions Smile CDR calls: %s. The script will never be called.", description, strings.Join(functions, ", ")),
+				AttributePath: k,
+			},
+		}
+	}
+}
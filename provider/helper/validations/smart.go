@@ -0,0 +1,150 @@
+package validations
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+var (
+	// SMART App Launch capabilities, as listed at https://hl7.org/fhir/smart-app-launch/conformance.html#capabilities
+	smartCapabilities = map[string]bool{
+		"authorize-post":                 true,
+		"client-confidential-asymmetric": true,
+		"client-confidential-symmetric":  true,
+		"client-public":                  true,
+		"context-banner":                 true,
+		"context-ehr-encounter":          true,
+		"context-ehr-patient":            true,
+		"context-standalone-encounter":   true,
+		"context-standalone-patient":     true,
+		"context-style":                  true,
+		"launch-ehr":                     true,
+		"launch-standalone":              true,
+		"permission-offline":             true,
+		"permission-online":              true,
+		"permission-patient":             true,
+		"permission-user":                true,
+		"permission-v1":                  true,
+		"permission-v2":                  true,
+		"sso-openid-connect":             true,
+	}
+
+	// Scopes that do not grant access to FHIR resources: OpenID Connect, SMART launch context and refresh
+	// token scopes, and the Smile CDR scope granting the authorities of the user.
+	smartNonResourceScopes = map[string]bool{
+		"address":                  true,
+		"cdr_all_user_authorities": true,
+		"email":                    true,
+		"fhirUser":                 true,
+		"launch":                   true,
+		"launch/encounter":         true,
+		"launch/patient":           true,
+		"offline_access":           true,
+		"online_access":            true,
+		"openid":                   true,
+		"phone":                    true,
+		"profile":                  true,
+	}
+
+	// <context>/<resource type>.<permissions>[?<query>], e.g. patient/Observation.read or user/*.cruds
+	smartResourceScopePattern = regexp.MustCompile(`^(patient|user|system)/([A-Za-z]+|\*)\.([a-z*]+)(?:\?(.*))?$`)
+	smartV2PermissionsPattern = regexp.MustCompile(`^c?r?u?d?s?$`)
+	smartScopeQueryPattern    = regexp.MustCompile(`^[A-Za-z0-9_.:-]+=[^&=\s]+(?:&[A-Za-z0-9_.:-]+=[^&=\s]+)*$`)
+)
+
+// SmartCapabilities returns the sorted SMART App Launch capabilities known to the provider.
+func SmartCapabilities() []string {
+	names := make([]string, 0, len(smartCapabilities))
+	for name := range smartCapabilities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func IsSmartCapability(i interface{}, k cty.Path) diag.Diagnostics {
+	value, ok := i.(string)
+	if !ok {
+		return diag.Diagnostics{
+			diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Invalid type for the field",
+				Detail:   fmt.Sprintf("Expected a string, but got %T", i),
+			},
+		}
+	}
+	if !smartCapabilities[value] {
+		return diag.Diagnostics{
+			diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Invalid SMART capability",
+				Detail:   fmt.Sprintf("'%s' is not a SMART App Launch capability, expected one of %s", value, strings.Join(SmartCapabilities(), ", ")),
+			},
+		}
+	}
+	return nil
+}
+
+// ValidateSmartScope checks that the scope is an OpenID Connect or SMART launch scope, or a SMART v1
+// (patient/*.read) or v2 (patient/Observation.rs?category=laboratory) resource scope.
+func ValidateSmartScope(scope string) error {
+	if smartNonResourceScopes[scope] {
+		return nil
+	}
+	match := smartResourceScopePattern.FindStringSubmatch(scope)
+	if match == nil {
+		return fmt.Errorf("'%s' is neither a known OpenID Connect or SMART launch scope, nor a SMART resource scope such as patient/Observation.read or patient/Observation.rs", scope)
+	}
+	resourceType, permissions, query := match[2], match[3], match[4]
+
+	if resourceType != "*" && !IsFhirResourceType(resourceType) {
+		return fmt.Errorf("'%s' is not a FHIR R4 resource type, in the scope '%s'", resourceType, scope)
+	}
+	switch {
+	case permissions == "read" || permissions == "write" || permissions == "*":
+		if strings.Contains(scope, "?") {
+			return fmt.Errorf("the SMART v1 scope '%s' cannot have a query, which only SMART v2 scopes such as %s/%s.rs?%s support", scope, match[1], resourceType, query)
+		}
+	case permissions != "" && smartV2PermissionsPattern.MatchString(permissions):
+		if strings.Contains(scope, "?") && !smartScopeQueryPattern.MatchString(query) {
+			return fmt.Errorf("'%s' is not a query of search parameters such as category=laboratory, in the scope '%s'", query, scope)
+		}
+	default:
+		return fmt.Errorf("'%s' are not the permissions of a SMART scope, expected read, write or * (v1), or any of c, r, u, d and s in this order (v2), in the scope '%s'", permissions, scope)
+	}
+	return nil
+}
+
+// IsWildcardSystemScope tells whether the scope is a SMART resource scope granting access to every
+// resource type of the server, such as system/*.read or system/*.cruds.
+func IsWildcardSystemScope(scope string) bool {
+	return strings.HasPrefix(scope, "system/*.") && ValidateSmartScope(scope) == nil
+}
+
+func IsSmartScope(i interface{}, k cty.Path) diag.Diagnostics {
+	value, ok := i.(string)
+	if !ok {
+		return diag.Diagnostics{
+			diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Invalid type for the field",
+				Detail:   fmt.Sprintf("Expected a string, but got %T", i),
+			},
+		}
+	}
+	if err := ValidateSmartScope(value); err != nil {
+		return diag.Diagnostics{
+			diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Invalid SMART scope",
+				Detail:   err.Error(),
+			},
+		}
+	}
+	return nil
+}
@@ -0,0 +1,401 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+)
+
+// The templates of a login skin directory, by filename, and the template attribute each one sets.
+var loginSkinTemplates = []struct {
+	filename  string
+	attribute string
+}{
+	{"login.html", "smart_login_skin_login_template"},
+	{"login_oauth2.html", "smart_login_skin_federated_oath2_template"},
+	{"approve.html", "smart_login_skin_approval_template"},
+	{"context_selection.html", "smart_login_skin_context_selection_template"},
+	{"2fa.html", "smart_login_skin_2fa_template"},
+	{"terms.html", "smart_login_skin_terms_of_service_template"},
+	{"error.html", "smart_login_skin_error_template"},
+	{"session_management.html", "smart_login_skin_session_management_template"},
+	{"register_step1.html", "smart_login_skin_user_registration_template_step1"},
+	{"register_step2.html", "smart_login_skin_user_registration_template_step2"},
+	{"forgot_password_step1.html", "smart_login_skin_user_registration_forgot_password_template_step1"},
+	{"forgot_password_step2.html", "smart_login_skin_user_registration_forgot_password_template_step2"},
+	{"forgot_password_step3.html", "smart_login_skin_user_registration_forgot_password_template_step3"},
+}
+
+const loginSkinWebJarIdAttribute = "smart_login_skin_webjar_id"
+
+// Thymeleaf fragment expressions referencing another template, e.g. th:replace="~{fragments/header :: header}".
+var loginSkinReferencePattern = regexp.MustCompile(`(?:th:(?:insert|replace|include)|layout:decorate)\s*=\s*"\s*~?\{?\s*([^:}"\s]*)`)
+
+func loginSkinTemplateAttributes() []string {
+	attributes := make([]string, len(loginSkinTemplates))
+	for i, template := range loginSkinTemplates {
+		attributes[i] = template.attribute
+	}
+	return attributes
+}
+
+func loginSkinSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:          schema.TypeList,
+		Optional:      true,
+		MaxItems:      1,
+		ConflictsWith: loginSkinTemplateAttributes(),
+		Description:   "A directory of Thymeleaf templates skinning the login and approval pages, in place of the `smart_login_skin_*_template` attributes. Each well-known file found in it sets the template option of its page: " + loginSkinTemplateList() + ". Thymeleaf fragments referenced by the templates must be in the directory too. Changes to its files are detected from `login_skin_sha256`.",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"directory": {
+					Type:        schema.TypeString,
+					Required:    true,
+					Description: "The path of the local directory holding the templates.",
+				},
+				"webjar": {
+					Type:        schema.TypeList,
+					Optional:    true,
+					MaxItems:    1,
+					Description: "Packages the directory as a WebJar, on apply, and sets `smart_login_skin_webjar_id` to it. The WebJar must then be installed on the server, e.g. in its `customerlib` directory.",
+					Elem: &schema.Resource{
+						Schema: map[string]*schema.Schema{
+							"group_id": {
+								Type:     schema.TypeString,
+								Required: true,
+							},
+							"artifact_id": {
+								Type:     schema.TypeString,
+								Required: true,
+							},
+							"version": {
+								Type:     schema.TypeString,
+								Required: true,
+							},
+							"output_path": {
+								Type:        schema.TypeString,
+								Required:    true,
+								Description: "The path of the WebJar file to write.",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func loginSkinTemplateList() string {
+	var items []string
+	for _, template := range loginSkinTemplates {
+		items = append(items, fmt.Sprintf("`%s` (`%s`)", template.filename, template.attribute))
+	}
+	return strings.Join(items, ", ")
+}
+
+// loginSkin holds the files of a login skin directory, by their slash separated path in it.
+type loginSkin struct {
+	directory string
+	files     map[string][]byte
+}
+
+func readLoginSkin(directory string) (*loginSkin, error) {
+	skin := &loginSkin{directory: directory, files: map[string][]byte{}}
+	err := filepath.WalkDir(directory, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(entry.Name(), ".") && name != directory {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		relative, err := filepath.Rel(directory, name)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		skin.files[filepath.ToSlash(relative)] = content
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the login skin: %w", err)
+	}
+	return skin, nil
+}
+
+// templates returns the value of the template attributes set by the skin, the path of their file
+// within the WebJar.
+func (skin *loginSkin) templates() map[string]string {
+	templates := map[string]string{}
+	for _, template := range loginSkinTemplates {
+		if _, ok := skin.files[template.filename]; ok {
+			templates[template.attribute] = "/" + template.filename
+		}
+	}
+	return templates
+}
+
+// validate checks that the skin has at least one well-known template, and that every template
+// referenced by a Thymeleaf fragment expression is in it.
+func (skin *loginSkin) validate() []string {
+	var problems []string
+	if len(skin.templates()) == 0 {
+		var filenames []string
+		for _, template := range loginSkinTemplates {
+			filenames = append(filenames, template.filename)
+		}
+		problems = append(problems, fmt.Sprintf("%s holds none of the login skin templates: %s", skin.directory, strings.Join(filenames, ", ")))
+	}
+
+	for _, name := range skin.paths() {
+		if !strings.HasSuffix(name, ".html") {
+			continue
+		}
+		for _, match := range loginSkinReferencePattern.FindAllStringSubmatch(string(skin.files[name]), -1) {
+			reference := strings.TrimPrefix(match[1], "/")
+			if reference == "" || reference == "this" || strings.ContainsAny(reference, "$*#@") {
+				continue // a fragment of the template itself, or an expression
+			}
+			if path.Ext(reference) == "" {
+				reference += ".html"
+			}
+			if _, ok := skin.files[reference]; !ok {
+				problems = append(problems, fmt.Sprintf("%s references the template %s, which is not in %s", name, reference, skin.directory))
+			}
+		}
+	}
+	return problems
+}
+
+func (skin *loginSkin) paths() []string {
+	paths := make([]string, 0, len(skin.files))
+	for name := range skin.files {
+		paths = append(paths, name)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// hash returns the hex SHA-256 hash of the paths and contents of the files of the skin. Text files, those
+// of valid UTF-8 without NUL bytes, are normalized as scripts are, so that a checkout on Windows hashes like any other.
+func (skin *loginSkin) hash() string {
+	h := sha256.New()
+	for _, name := range skin.paths() {
+		content := skin.files[name]
+		if utf8.Valid(content) && !bytes.ContainsRune(content, 0) {
+			content = []byte(normalizeScript(string(content)))
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", name, len(content))
+		h.Write(content)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeWebJar packages the skin as a WebJar: its files under META-INF/resources/webjars/<artifactId>/<version>,
+// along with the Maven coordinates. The entries have a fixed time, so that the same skin always makes the
+// same file.
+func (skin *loginSkin) writeWebJar(output string, groupId string, artifactId string, version string) error {
+	file, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("unable to write the login skin WebJar: %w", err)
+	}
+	defer file.Close()
+
+	modified := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	jar := zip.NewWriter(file)
+	add := func(name string, content []byte) error {
+		w, err := jar.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(content)
+		return err
+	}
+
+	if err := add("META-INF/MANIFEST.MF", []byte("Manifest-Version: 1.0\r\nCreated-By: terraform-provider-smilecdr\r\n\r\n")); err != nil {
+		return err
+	}
+	properties := fmt.Sprintf("groupId=%s\nartifactId=%s\nversion=%s\n", groupId, artifactId, version)
+	if err := add(fmt.Sprintf("META-INF/maven/%s/%s/pom.properties", groupId, artifactId), []byte(properties)); err != nil {
+		return err
+	}
+	for _, name := range skin.paths() {
+		if err := add(fmt.Sprintf("META-INF/resources/webjars/%s/%s/%s", artifactId, version, name), skin.files[name]); err != nil {
+			return err
+		}
+	}
+
+	if err := jar.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// loginSkinWebJar returns the settings of the webjar block of the login skin, if it has one.
+func loginSkinWebJar(loginSkin map[string]interface{}) (map[string]interface{}, bool) {
+	webjars, _ := loginSkin["webjar"].([]interface{})
+	if len(webjars) == 0 || webjars[0] == nil {
+		return nil, false
+	}
+	return webjars[0].(map[string]interface{}), true
+}
+
+func loginSkinBlock(d interface{ Get(string) interface{} }) (map[string]interface{}, bool) {
+	blocks := d.Get("login_skin").([]interface{})
+	if len(blocks) == 0 || blocks[0] == nil {
+		return nil, false
+	}
+	return blocks[0].(map[string]interface{}), true
+}
+
+// loginSkinDiff reads the login skin directory at plan time, fails the plan when it is not valid, and
+// plans a new hash when its files changed.
+func loginSkinDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if !d.NewValueKnown("login_skin") {
+		return d.SetNewComputed("login_skin_sha256")
+	}
+
+	hash := ""
+	if block, ok := loginSkinBlock(d); ok {
+		skin, err := readLoginSkin(block["directory"].(string))
+		if err != nil {
+			return fmt.Errorf("login_skin: %w", err)
+		}
+		problems := skin.validate()
+		if _, ok := loginSkinWebJar(block); ok {
+			if _, ok := d.GetOk(loginSkinWebJarIdAttribute); ok {
+				problems = append(problems, fmt.Sprintf("%s cannot be set along with a webjar block, which sets it", loginSkinWebJarIdAttribute))
+			}
+		}
+		if len(problems) > 0 {
+			return fmt.Errorf("login_skin: %s", strings.Join(problems, "\nlogin_skin: "))
+		}
+		hash = skin.hash()
+	}
+
+	if hash != d.Get("login_skin_sha256").(string) {
+		return d.SetNew("login_skin_sha256", hash)
+	}
+	return nil
+}
+
+// expandLoginSkin sets the template options of the login skin, and packages its WebJar when it has one.
+// When the login skin is removed, the template options it set are reset.
+func expandLoginSkin(d *schema.ResourceData, moduleConfig *smilecdr.ModuleConfig) error {
+	block, ok := loginSkinBlock(d)
+	if !ok {
+		if d.HasChange("login_skin") {
+			for _, template := range loginSkinTemplates {
+				if _, set := d.GetOk(template.attribute); !set {
+					moduleConfig.SetOption(smartOutboundOptionKey(template.attribute), "")
+				}
+			}
+			resetLoginSkinWebJar(d, moduleConfig)
+		}
+		return nil
+	}
+
+	skin, err := readLoginSkin(block["directory"].(string))
+	if err != nil {
+		return fmt.Errorf("login_skin: %w", err)
+	}
+	templates := skin.templates()
+	for _, template := range loginSkinTemplates {
+		moduleConfig.SetOption(smartOutboundOptionKey(template.attribute), templates[template.attribute])
+	}
+
+	if webjar, ok := loginSkinWebJar(block); ok {
+		groupId, artifactId, version := webjar["group_id"].(string), webjar["artifact_id"].(string), webjar["version"].(string)
+		if err := skin.writeWebJar(webjar["output_path"].(string), groupId, artifactId, version); err != nil {
+			return fmt.Errorf("login_skin: %w", err)
+		}
+		moduleConfig.SetOption(smartOutboundOptionKey(loginSkinWebJarIdAttribute), fmt.Sprintf("%s:%s:%s", groupId, artifactId, version))
+	} else {
+		resetLoginSkinWebJar(d, moduleConfig)
+	}
+	return nil
+}
+
+// resetLoginSkinWebJar clears the WebJar ID set by a webjar block that was removed, alone or with its skin,
+// unless smart_login_skin_webjar_id now sets it.
+func resetLoginSkinWebJar(d *schema.ResourceData, moduleConfig *smilecdr.ModuleConfig) {
+	old, _ := d.GetChange("login_skin")
+	blocks, _ := old.([]interface{})
+	if len(blocks) == 0 || blocks[0] == nil {
+		return
+	}
+	if _, ok := loginSkinWebJar(blocks[0].(map[string]interface{})); !ok {
+		return
+	}
+	if _, set := d.GetOk(loginSkinWebJarIdAttribute); !set {
+		moduleConfig.SetOption(smartOutboundOptionKey(loginSkinWebJarIdAttribute), "")
+	}
+}
+
+// flattenLoginSkin leaves the template attributes, and the WebJar ID of a webjar block, unset when a login
+// skin sets them. Templates changed on the server clear the hash of the skin, which plans an update to set
+// them again.
+func flattenLoginSkin(d *schema.ResourceData, moduleConfig smilecdr.ModuleConfig) {
+	block, ok := loginSkinBlock(d)
+	if !ok {
+		return
+	}
+	expected := map[string]string{}
+	for _, template := range loginSkinTemplates {
+		d.Set(template.attribute, nil)
+	}
+	if webjar, ok := loginSkinWebJar(block); ok {
+		d.Set(loginSkinWebJarIdAttribute, nil)
+		expected[loginSkinWebJarIdAttribute] = fmt.Sprintf("%s:%s:%s", webjar["group_id"], webjar["artifact_id"], webjar["version"])
+	}
+
+	skin, err := readLoginSkin(block["directory"].(string))
+	if err != nil {
+		return // reported by the plan
+	}
+	templates := skin.templates()
+	for _, template := range loginSkinTemplates {
+		expected[template.attribute] = templates[template.attribute]
+	}
+	for attribute, value := range expected {
+		if actual, _ := moduleConfig.LookupOptionOk(smartOutboundOptionKey(attribute)); actual != value {
+			d.Set("login_skin_sha256", "")
+			return
+		}
+	}
+}
+
+func smartOutboundOptionKey(attribute string) string {
+	for _, option := range smartOutboundOptions {
+		if option.attribute == attribute {
+			return option.key
+		}
+	}
+	panic(fmt.Sprintf("no SECURITY_OUT_SMART option for %s", attribute))
+}
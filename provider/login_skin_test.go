@@ -0,0 +1,282 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr/smilecdrtest"
+)
+
+func testLoginSkinDirectory(t *testing.T, files map[string]string) string {
+	t.Helper()
+	directory := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(directory, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return directory
+}
+
+func TestLoginSkin(t *testing.T) {
+	files := map[string]string{
+		"login.html":            "<html>\n<head th:replace=\"~{fragments/head :: head}\"></head>\n<div th:insert=\"~{:: local}\"></div>\n</html>\n",
+		"approve.html":          "<html layout:decorate=\"~{layout}\">\n</html>\n",
+		"layout.html":           "<html></html>\n",
+		"fragments/head.html":   "<head th:fragment=\"head\"></head>\n",
+		"css/skin.css":          "body { color: #333; }\n",
+		".git/config":           "[core]\n",
+		"notes.md":              "Not a template.\n",
+		"forgot_password_x.htm": "<html></html>\n",
+	}
+	directory := testLoginSkinDirectory(t, files)
+
+	skin, err := readLoginSkin(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"smart_login_skin_login_template":    "/login.html",
+		"smart_login_skin_approval_template": "/approve.html",
+	}
+	if templates := skin.templates(); !reflect.DeepEqual(templates, expected) {
+		t.Errorf("the skin sets the templates %v, expected %v", templates, expected)
+	}
+	if _, ok := skin.files[".git/config"]; ok {
+		t.Error("the skin holds hidden files")
+	}
+	if problems := skin.validate(); len(problems) > 0 {
+		t.Errorf("a valid skin has problems %q", problems)
+	}
+
+	// The same files checked out with CRLF line endings hash alike, other content does not.
+	crlf := map[string]string{}
+	for name, content := range files {
+		crlf[name] = strings.ReplaceAll(content, "\n", "\r\n")
+	}
+	crlfSkin, _ := readLoginSkin(testLoginSkinDirectory(t, crlf))
+	if crlfSkin.hash() != skin.hash() {
+		t.Error("a checkout with CRLF line endings does not hash alike")
+	}
+	files["css/skin.css"] = "body { color: #000; }\n"
+	changedSkin, _ := readLoginSkin(testLoginSkinDirectory(t, files))
+	if changedSkin.hash() == skin.hash() {
+		t.Error("a changed file does not change the hash")
+	}
+
+	delete(files, "fragments/head.html")
+	delete(files, "approve.html")
+	brokenSkin, _ := readLoginSkin(testLoginSkinDirectory(t, files))
+	if problems := brokenSkin.validate(); len(problems) != 1 || !strings.Contains(problems[0], "login.html references the template fragments/head.html, which is not in") {
+		t.Errorf("a skin missing a fragment has problems %q", problems)
+	}
+	emptySkin, _ := readLoginSkin(testLoginSkinDirectory(t, map[string]string{"index.html": "<html></html>"}))
+	if problems := emptySkin.validate(); len(problems) != 1 || !strings.Contains(problems[0], "holds none of the login skin templates: login.html") {
+		t.Errorf("a skin without templates has problems %q", problems)
+	}
+}
+
+func TestLoginSkinWebJar(t *testing.T) {
+	skin, err := readLoginSkin(testLoginSkinDirectory(t, map[string]string{
+		"login.html":   "<html></html>",
+		"css/skin.css": "body {}",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(t.TempDir(), "skin.jar")
+	if err := skin.writeWebJar(output, "org.example", "login-skin", "1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	jar, err := zip.OpenReader(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer jar.Close()
+
+	var names []string
+	for _, file := range jar.File {
+		names = append(names, file.Name)
+	}
+	sort.Strings(names)
+	expected := []string{
+		"META-INF/MANIFEST.MF",
+		"META-INF/maven/org.example/login-skin/pom.properties",
+		"META-INF/resources/webjars/login-skin/1.0.0/css/skin.css",
+		"META-INF/resources/webjars/login-skin/1.0.0/login.html",
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("the WebJar holds %v, expected %v", names, expected)
+	}
+}
+
+// TestLoginSkinApply applies a SMART outbound security module skinned from a directory, and checks the
+// template options it sets and the plans that follow changes to the directory.
+func TestLoginSkinApply(t *testing.T) {
+	server := smilecdrtest.NewServer()
+	c := smilecdr.NewClient(context.Background(), server.Start(), "admin", "password")
+	defer server.Close()
+
+	directory := testLoginSkinDirectory(t, map[string]string{
+		"login.html": "<html></html>\n",
+		"2fa.html":   "<html></html>\n",
+	})
+	output := filepath.Join(t.TempDir(), "skin.jar")
+
+	r := resourceSmartOutboundSecurity()
+	config := map[string]interface{}{
+		"node_id":                              "Master",
+		"module_id":                            "smart_auth",
+		"http_listener_port":                   9200,
+		"oidc_issuer_url":                      "https://auth.example.org",
+		"smart_authorization_scopes_supported": []interface{}{"openid"},
+		"login_skin": []interface{}{map[string]interface{}{
+			"directory": directory,
+			"webjar": []interface{}{map[string]interface{}{
+				"group_id":    "org.example",
+				"artifact_id": "login-skin",
+				"version":     "1.0.0",
+				"output_path": output,
+			}},
+		}},
+	}
+	plan := func(state *terraform.InstanceState) *terraform.InstanceDiff {
+		diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), c)
+		if err != nil {
+			t.Fatalf("plan failed: %s", err)
+		}
+		return diff
+	}
+
+	state := testApply(t, r, nil, config, c)
+	module := server.Modules["Master/smart_auth"]
+	for key, expected := range map[string]string{
+		"skin.login_page.template":   "/login.html",
+		"skin.tfa_page.template":     "/2fa.html",
+		"skin.approve_page.template": "",
+		"skin.webjar_id":             "org.example:login-skin:1.0.0",
+	} {
+		if value, _ := module.LookupOptionOk(key); value != expected {
+			t.Errorf("%s is %q on the server, expected %q", key, value, expected)
+		}
+	}
+	if _, err := os.Stat(output); err != nil {
+		t.Errorf("the WebJar was not written: %s", err)
+	}
+	if state.Attributes["smart_login_skin_login_template"] != "" || state.Attributes["smart_login_skin_webjar_id"] != "" {
+		t.Errorf("the state holds the options set by the skin: %v", state.Attributes)
+	}
+	if diff := plan(state); diff != nil && !diff.Empty() {
+		t.Errorf("an unchanged skin plans %v", diff.Attributes)
+	}
+
+	if err := os.WriteFile(filepath.Join(directory, "approve.html"), []byte("<html></html>\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if diff := plan(state); diff == nil || diff.Attributes["login_skin_sha256"] == nil {
+		t.Fatal("a new template does not plan a new hash")
+	}
+	state = testApply(t, r, state, config, c)
+	module = server.Modules["Master/smart_auth"]
+	if value, _ := module.LookupOptionOk("skin.approve_page.template"); value != "/approve.html" {
+		t.Errorf("the new template did not reach the server, which holds %q", value)
+	}
+
+	module.SetOption("skin.tfa_page.template", "/usertfa.html")
+	server.Modules["Master/smart_auth"] = module
+	state, diags := r.RefreshWithoutUpgrade(context.Background(), state, c)
+	if diags.HasError() {
+		t.Fatalf("refresh failed: %+v", diags)
+	}
+	if diff := plan(state); diff == nil || diff.Attributes["login_skin_sha256"] == nil {
+		t.Error("a template changed on the server does not plan a new hash")
+	}
+
+	if err := os.WriteFile(filepath.Join(directory, "login.html"), []byte("<html th:replace=\"~{missing :: page}\"></html>\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), c); err == nil || !strings.Contains(err.Error(), "login_skin: login.html references the template missing.html") {
+		t.Errorf("a missing template plans with %v", err)
+	}
+}
+
+func TestLoginSkinRemoveWebJar(t *testing.T) {
+	server := smilecdrtest.NewServer()
+	c := smilecdr.NewClient(context.Background(), server.Start(), "admin", "password")
+	defer server.Close()
+
+	directory := testLoginSkinDirectory(t, map[string]string{"login.html": "<html></html>\n"})
+	webjar := []interface{}{map[string]interface{}{
+		"group_id":    "org.example",
+		"artifact_id": "login-skin",
+		"version":     "1.0.0",
+		"output_path": filepath.Join(t.TempDir(), "skin.jar"),
+	}}
+	config := func(loginSkin []interface{}) map[string]interface{} {
+		config := map[string]interface{}{
+			"node_id":                              "Master",
+			"module_id":                            "smart_auth",
+			"http_listener_port":                   9200,
+			"oidc_issuer_url":                      "https://auth.example.org",
+			"smart_authorization_scopes_supported": []interface{}{"openid"},
+		}
+		if loginSkin != nil {
+			config["login_skin"] = loginSkin
+		}
+		return config
+	}
+	option := func(key string) string {
+		module := server.Modules["Master/smart_auth"]
+		value, _ := module.LookupOptionOk(key)
+		return value
+	}
+
+	r := resourceSmartOutboundSecurity()
+	withWebJar := []interface{}{map[string]interface{}{"directory": directory, "webjar": webjar}}
+	withoutWebJar := []interface{}{map[string]interface{}{"directory": directory}}
+
+	state := testApply(t, r, nil, config(withWebJar), c)
+	state = testApply(t, r, state, config(withoutWebJar), c)
+	if value := option("skin.webjar_id"); value != "" {
+		t.Errorf("removing the webjar block leaves the WebJar ID %q on the server", value)
+	}
+	if value := option("skin.login_page.template"); value != "/login.html" {
+		t.Errorf("removing the webjar block changed the login template to %q", value)
+	}
+
+	state = testApply(t, r, state, config(withWebJar), c)
+	if value := option("skin.webjar_id"); value != "org.example:login-skin:1.0.0" {
+		t.Fatalf("the WebJar ID is %q on the server", value)
+	}
+	state = testApply(t, r, state, config(nil), c)
+	if value := option("skin.webjar_id"); value != "" {
+		t.Errorf("removing the login skin leaves the WebJar ID %q on the server", value)
+	}
+	if diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(config(nil)), c); err != nil || (diff != nil && !diff.Empty()) {
+		t.Errorf("a removed login skin plans %v, %v", diff, err)
+	}
+
+	state = testApply(t, r, state, config(withWebJar), c)
+	explicit := config(withoutWebJar)
+	explicit["smart_login_skin_webjar_id"] = "org.example:other-skin:2.0.0"
+	testApply(t, r, state, explicit, c)
+	if value := option("skin.webjar_id"); value != "org.example:other-skin:2.0.0" {
+		t.Errorf("a WebJar ID set directly is %q on the server", value)
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+)
+
+func forceArchiveSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeBool,
+		Optional:    true,
+		Default:     false,
+		Description: "Archive the module on destroy even if other modules depend on it, which stops them from working. By default the destroy fails, listing the dependent modules.",
+	}
+}
+
+func repointDependentsToSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		Description: "The ID of a module replacing this one. On destroy, every module depending on this one is stopped, made to depend on the replacement instead, and started again, before this module is archived. The re-pointed modules are listed in a warning.",
+	}
+}
+
+// moduleDependents returns the modules listing moduleId in their dependencies, sorted by module id.
+func moduleDependents(modules []smilecdr.ModuleConfig, moduleId string) []smilecdr.ModuleConfig {
+	var dependents []smilecdr.ModuleConfig
+	for _, module := range modules {
+		for _, dependency := range module.Dependencies {
+			if dependency.ModuleId == moduleId {
+				dependents = append(dependents, module)
+				break
+			}
+		}
+	}
+	sort.Slice(dependents, func(i, j int) bool { return dependents[i].ModuleId < dependents[j].ModuleId })
+	return dependents
+}
+
+// archiveModule archives the module of the resource from a node, after checking the live module graph
+// of the node for modules depending on it. Dependents are re-pointed to `repoint_dependents_to` when
+// set, with a warning naming them; otherwise the module is only archived with dependents when
+// `force_archive` is true.
+func archiveModule(ctx context.Context, c *smilecdr.Client, d *schema.ResourceData, nodeId string) (diag.Diagnostics, error) {
+	moduleId := d.Get("module_id").(string)
+	replacementId := d.Get("repoint_dependents_to").(string)
+	force := d.Get("force_archive").(bool)
+
+	modules, err := c.GetNodeModuleConfigs(ctx, nodeId)
+	if err != nil {
+		return nil, fmt.Errorf("unable to check the modules depending on %s: %w", moduleId, err)
+	}
+	dependents := moduleDependents(modules, moduleId)
+	ids := make([]string, len(dependents))
+	for i, dependent := range dependents {
+		ids[i] = dependent.ModuleId
+	}
+
+	var diags diag.Diagnostics
+	if len(dependents) > 0 && replacementId != "" {
+		if err := repointDependents(ctx, c, nodeId, moduleId, replacementId, modules, dependents); err != nil {
+			return nil, err
+		}
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Dependent modules re-pointed",
+			Detail:   fmt.Sprintf("Before archiving module %s from node %s, these modules were stopped, made to depend on %s instead, and started again: %s", moduleId, nodeId, replacementId, strings.Join(ids, ", ")),
+		})
+	} else if len(dependents) > 0 && !force {
+		return nil, fmt.Errorf("module %s cannot be archived, these modules depend on it: %s. Set repoint_dependents_to to move them to another module, or force_archive = true to archive it anyway", moduleId, strings.Join(ids, ", "))
+	} else if len(dependents) > 0 {
+		tflog.Warn(ctx, fmt.Sprintf("Archiving module %s, which %d modules depend on", moduleId, len(dependents)))
+	}
+
+	return diags, c.DeleteModuleConfig(ctx, nodeId, moduleId)
+}
+func repointDependents(ctx context.Context, c *smilecdr.Client, nodeId string, moduleId string, replacementId string, modules []smilecdr.ModuleConfig, dependents []smilecdr.ModuleConfig) error {
+	var replacement *smilecdr.ModuleConfig
+	for i := range modules {
+		if modules[i].ModuleId == replacementId {
+			replacement = &modules[i]
+		}
+	}
+	if replacement == nil || replacementId == moduleId {
+		return fmt.Errorf("repoint_dependents_to: module %s does not exist on node %s", replacementId, nodeId)
+	}
+
+	for _, dependent := range dependents {
+		if dependent.ModuleId == replacementId {
+			return fmt.Errorf("repoint_dependents_to: module %s depends on %s itself", replacementId, moduleId)
+		}
+		for _, dependency := range dependent.Dependencies {
+			moduleTypes := dependencyModuleTypes[dependency.Type]
+			if dependency.ModuleId == moduleId && moduleTypes != nil && !contains(moduleTypes, replacement.ModuleType) {
+				return fmt.Errorf("repoint_dependents_to: module %s is a %s module, which cannot fill the %s dependency of %s", replacementId, replacement.ModuleType, dependency.Type, dependent.ModuleId)
+			}
+		}
+	}
+
+	for _, dependent := range dependents {
+		if err := c.StopModule(ctx, nodeId, dependent.ModuleId); err != nil {
+			return fmt.Errorf("stopping dependent module %s: %w", dependent.ModuleId, err)
+		}
+
+		dependencies := make([]smilecdr.ModuleDependency, len(dependent.Dependencies))
+		for i, dependency := range dependent.Dependencies {
+			if dependency.ModuleId == moduleId {
+				dependency.ModuleId = replacementId
+			}
+			dependencies[i] = dependency
+		}
+		dependent.Dependencies = dependencies
+		if _, err := c.PutModuleConfig(ctx, nodeId, dependent); err != nil {
+			return fmt.Errorf("re-pointing dependent module %s to %s: %w", dependent.ModuleId, replacementId, err)
+		}
+
+		if err := c.StartModule(ctx, nodeId, dependent.ModuleId); err != nil {
+			return fmt.Errorf("starting dependent module %s: %w", dependent.ModuleId, err)
+		}
+		tflog.Info(ctx, fmt.Sprintf("Module %s now depends on %s instead of %s", dependent.ModuleId, replacementId, moduleId))
+	}
+	return nil
+}
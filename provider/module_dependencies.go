@@ -0,0 +1,200 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+)
+
+var persistenceModuleTypes = []string{"PERSISTENCE_DSTU2", "PERSISTENCE_DSTU3", "PERSISTENCE_R4", "PERSISTENCE_R4B", "PERSISTENCE_R5"}
+
+// Module types that can fill each dependency type. Dependency types not listed are only checked for
+// the existence of the module.
+var dependencyModuleTypes = map[string][]string{
+	"SECURITY_IN_UP":     {"SECURITY_IN_LOCAL", "SECURITY_IN_LDAP", "SECURITY_IN_SCRIPTED"},
+	"SECURITY_IN_SAML":   {"SECURITY_IN_SAML"},
+	"SECURITY_OUT_SMART": {"SECURITY_OUT_SMART"},
+	"PERSISTENCE_ALL":    persistenceModuleTypes,
+}
+
+// The dependency attributes of smilecdr_smart_outbound_security. Each one holds the dependency type,
+// for the module of a fixed id.
+var smartOutboundDependencies = []struct {
+	attribute   string
+	moduleId    string
+	moduleTypes []string
+}{
+	{"dependency_local_inbound_security", "local_security", []string{"SECURITY_IN_LOCAL"}},
+	{"dependency_fhir_persistence_module", "persistence", persistenceModuleTypes},
+	{"dependency_saml_authentication_module", "security_in_saml", []string{"SECURITY_IN_SAML"}},
+	{"dependency_self_registration_provider_module", "self_service_user_management", nil},
+}
+
+func smartOutboundDependencyAttributes() []string {
+	attributes := make([]string, len(smartOutboundDependencies))
+	for i, dependency := range smartOutboundDependencies {
+		attributes[i] = dependency.attribute
+	}
+	return attributes
+}
+
+type plannedDependency struct {
+	attribute   string
+	path        cty.Path
+	moduleId    string
+	moduleTypes []string
+}
+
+func (dependency plannedDependency) errorf(format string, a ...interface{}) attributeError {
+	return attributeError{attribute: dependency.attribute, path: dependency.path, message: fmt.Sprintf(format, a...)}
+}
+
+// dependenciesListDiff reads the planned dependencies of a resource with a `dependencies` list.
+func dependenciesListDiff(d *schema.ResourceDiff) []plannedDependency {
+	var planned []plannedDependency
+	for i, dependency := range d.Get("dependencies").([]interface{}) {
+		dependencyMap, ok := dependency.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		planned = append(planned, plannedDependency{
+			attribute:   fmt.Sprintf("dependencies.%d.module_id", i),
+			path:        cty.GetAttrPath("dependencies").IndexInt(i).GetAttr("module_id"),
+			moduleId:    dependencyMap["module_id"].(string),
+			moduleTypes: dependencyModuleTypes[dependencyMap["type"].(string)],
+		})
+	}
+	return planned
+}
+
+func smartOutboundDependenciesDiff(d *schema.ResourceDiff) []plannedDependency {
+	var planned []plannedDependency
+	for _, dependency := range smartOutboundDependencies {
+		if v, ok := d.GetOk(dependency.attribute); ok && v.(string) != "" {
+			planned = append(planned, plannedDependency{
+				attribute:   dependency.attribute,
+				path:        cty.GetAttrPath(dependency.attribute),
+				moduleId:    dependency.moduleId,
+				moduleTypes: dependency.moduleTypes,
+			})
+		}
+	}
+	return planned
+}
+
+// validateModuleDependenciesDiff checks that every dependency of the planned module that exists on the
+// server has a module type that fits the dependency, and does not depend back on the module, on every
+// node the module targets. A dependency that does not exist yet may be created by the same apply, so it
+// is only logged. A node that cannot be read at plan time is not checked.
+func validateModuleDependenciesDiff(attributes []string, planned func(d *schema.ResourceDiff) []plannedDependency) schema.CustomizeDiffFunc {
+	return func(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+		if d.Id() != "" && !d.HasChanges(append([]string{"node_ids"}, attributes...)...) {
+			return nil
+		}
+		for _, attribute := range append([]string{"node_id", "node_ids", "module_id"}, attributes...) {
+			if !d.NewValueKnown(attribute) {
+				return nil
+			}
+		}
+		c, ok := m.(*smilecdr.Client)
+		if !ok || c == nil {
+			return nil
+		}
+
+		dependencies := planned(d)
+		if len(dependencies) == 0 {
+			return nil
+		}
+
+		moduleId := d.Get("module_id").(string)
+		nodeIds := sortedStrings(d.Get("node_ids").(*schema.Set))
+		if len(nodeIds) == 0 {
+			nodeIds = []string{d.Get("node_id").(string)}
+		}
+
+		var errs []attributeError
+		for _, dependency := range dependencies {
+			if dependency.moduleId == moduleId {
+				errs = append(errs, dependency.errorf("module %s cannot depend on itself", moduleId))
+			}
+		}
+		for _, nodeId := range nodeIds {
+			modules, err := c.GetNodeModuleConfigs(ctx, nodeId)
+			if err != nil {
+				tflog.Warn(ctx, fmt.Sprintf("Unable to read the module configurations of node %s to check dependencies: %s", nodeId, err))
+				continue
+			}
+			errs = append(errs, nodeDependencyErrors(ctx, nodeId, moduleId, modules, dependencies)...)
+		}
+
+		return attributeErrors(errs)
+	}
+}
+
+func nodeDependencyErrors(ctx context.Context, nodeId string, moduleId string, modules []smilecdr.ModuleConfig, dependencies []plannedDependency) []attributeError {
+	byId := map[string]smilecdr.ModuleConfig{}
+	for _, module := range modules {
+		byId[module.ModuleId] = module
+	}
+
+	var errs []attributeError
+	for _, dependency := range dependencies {
+		target, exists := byId[dependency.moduleId]
+		switch {
+		case dependency.moduleId == moduleId:
+			continue
+		case !exists:
+			tflog.Warn(ctx, fmt.Sprintf("%s: module %s does not exist on node %s yet, it is expected to be created by the same apply", dependency.attribute, dependency.moduleId, nodeId))
+		case dependency.moduleTypes != nil && !contains(dependency.moduleTypes, target.ModuleType):
+			errs = append(errs, dependency.errorf("module %s is a %s module on node %s, expected one of %s", dependency.moduleId, target.ModuleType, nodeId, strings.Join(dependency.moduleTypes, ", ")))
+		default:
+			if cycle := dependencyCycle(byId, moduleId, dependency.moduleId); cycle != nil {
+				errs = append(errs, dependency.errorf("depending on module %s creates a cycle on node %s: %s", dependency.moduleId, nodeId, strings.Join(cycle, " -> ")))
+			}
+		}
+	}
+	return errs
+}
+
+// dependencyCycle returns the path from moduleId through dependencyId and back to moduleId in the current
+// module graph, or nil if dependencyId does not depend on moduleId, directly or not.
+func dependencyCycle(modules map[string]smilecdr.ModuleConfig, moduleId string, dependencyId string) []string {
+	visited := map[string]bool{}
+
+	var walk func(id string, path []string) []string
+	walk = func(id string, path []string) []string {
+		path = append(path, id)
+		if id == moduleId {
+			return path
+		}
+		if visited[id] {
+			return nil
+		}
+		visited[id] = true
+		for _, dependency := range modules[id].Dependencies {
+			if cycle := walk(dependency.ModuleId, path); cycle != nil {
+				return cycle
+			}
+		}
+		return nil
+	}
+
+	return walk(dependencyId, []string{moduleId})
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
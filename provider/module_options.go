@@ -0,0 +1,316 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+)
+
+type moduleOptionType int
+
+const (
+	optionString    moduleOptionType = iota
+	optionBool                       // "true" or "false"
+	optionInt                        // decimal
+	optionList                       // a list of strings, one per line
+	optionCommaList                  // a list of strings, separated by commas
+	optionSpaceList                  // a list of strings, separated by spaces
+	optionSet                        // a set of strings, one per line
+	optionCommaSet                   // a set of strings, separated by commas
+	optionSpaceSet                   // a set of strings, separated by spaces
+)
+
+// moduleOption maps an attribute of a module resource to a module configuration option. A table of
+// them generates the schema of the attributes, and their conversion to and from the module configuration.
+type moduleOption struct {
+	attribute      string
+	key            string
+	optionType     moduleOptionType
+	required       bool
+	defaultValue   interface{}
+	sensitive      bool
+	minimumVersion string // the first Smile CDR release supporting the option, if not every release
+	validate       schema.SchemaValidateDiagFunc
+	description    string
+	source         string // an attribute taking the path of a local file to read the option from, see scriptSource
+}
+
+func (option moduleOption) scriptSource() scriptSource {
+	return scriptSource{attribute: option.source, textAttribute: option.attribute, validate: option.validate}
+}
+
+func (option moduleOption) schema() *schema.Schema {
+	s := &schema.Schema{
+		Required:         option.required,
+		Optional:         !option.required,
+		Default:          option.defaultValue,
+		Sensitive:        option.sensitive,
+		ValidateDiagFunc: option.validate,
+		Description:      option.description,
+	}
+	if option.minimumVersion != "" {
+		s.Description = strings.TrimSpace(s.Description + " Requires Smile CDR " + option.minimumVersion + " or later.")
+	}
+
+	switch option.optionType {
+	case optionBool:
+		s.Type = schema.TypeBool
+	case optionInt:
+		s.Type = schema.TypeInt
+	case optionList, optionCommaList, optionSpaceList:
+		s.Type = schema.TypeList
+		s.Elem = &schema.Schema{Type: schema.TypeString, ValidateDiagFunc: option.validate}
+		s.ValidateDiagFunc = nil
+	case optionSet, optionCommaSet, optionSpaceSet:
+		// Sets cannot have a default: the default is sent when the module is created without the
+		// attribute, and read back from the server.
+		s.Type = schema.TypeSet
+		s.Elem = &schema.Schema{Type: schema.TypeString, ValidateDiagFunc: option.validate}
+		s.ValidateDiagFunc = nil
+		s.Default = nil
+		s.Computed = option.defaultValue != nil
+	default:
+		s.Type = schema.TypeString
+	}
+	return s
+}
+
+func (option moduleOption) isSet() bool {
+	return option.optionType == optionSet || option.optionType == optionCommaSet || option.optionType == optionSpaceSet
+}
+
+func (option moduleOption) separator() string {
+	switch option.optionType {
+	case optionCommaList, optionCommaSet:
+		return ","
+	case optionSpaceList, optionSpaceSet:
+		return " "
+	}
+	return "\n"
+}
+
+// format formats the value of the attribute as the value of the option. A nil value is a blank option.
+func (option moduleOption) format(v interface{}) string {
+	switch value := v.(type) {
+	case bool:
+		return strconv.FormatBool(value)
+	case int:
+		return strconv.Itoa(value)
+	case string:
+		return value
+	case []interface{}:
+		items := make([]string, 0, len(value))
+		for _, item := range value {
+			if item != nil {
+				items = append(items, item.(string))
+			}
+		}
+		return strings.Join(items, option.separator())
+	case *schema.Set:
+		items := make([]string, 0, value.Len())
+		for _, item := range value.List() {
+			items = append(items, item.(string))
+		}
+		sort.Strings(items)
+		return strings.Join(items, option.separator())
+	}
+	return ""
+}
+
+// parse parses the value of the option as the value of the attribute. Values that do not parse, such as
+// a blank boolean, are not known.
+func (option moduleOption) parse(value string) (interface{}, bool) {
+	switch option.optionType {
+	case optionBool:
+		b, err := strconv.ParseBool(value)
+		return b, err == nil
+	case optionInt:
+		i, err := strconv.Atoi(strings.TrimSpace(value))
+		return i, err == nil
+	case optionList, optionCommaList, optionSpaceList:
+		var items []interface{}
+		for _, item := range strings.Split(value, option.separator()) {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items, true
+	case optionSet, optionCommaSet, optionSpaceSet:
+		// Items of a set may also be separated by whitespace, e.g. a comma-separated set written
+		// "a, b" or spread over several lines.
+		items := strings.FieldsFunc(value, func(r rune) bool {
+			return unicode.IsSpace(r) || (option.optionType == optionCommaSet && r == ',')
+		})
+		set := schema.NewSet(schema.HashString, nil)
+		for _, item := range items {
+			set.Add(item)
+		}
+		return set, true
+	}
+	return value, true
+}
+
+// moduleOptionsSchema adds the attributes of the options to the schema of a module resource.
+func moduleOptionsSchema(options []moduleOption, s map[string]*schema.Schema) map[string]*schema.Schema {
+	for _, option := range options {
+		if _, exists := s[option.attribute]; exists {
+			panic(fmt.Sprintf("module option attribute %s is already in the schema", option.attribute))
+		}
+		s[option.attribute] = option.schema()
+	}
+	s = scriptSourcesSchema(moduleOptionScriptSources(options), s)
+	for _, option := range options {
+		if option.source != "" && option.minimumVersion != "" {
+			s[option.source].Description += " Requires Smile CDR " + option.minimumVersion + " or later."
+		}
+	}
+	return s
+}
+
+// moduleOptionScriptSources returns the script sources of the options that have one.
+func moduleOptionScriptSources(options []moduleOption) []scriptSource {
+	var sources []scriptSource
+	for _, option := range options {
+		if option.source != "" {
+			sources = append(sources, option.scriptSource())
+		}
+	}
+	return sources
+}
+
+// expandModuleOptions returns the options of the module configuration set by the resource. Options with
+// a script source set are read from its local file.
+func expandModuleOptions(d *schema.ResourceData, options []moduleOption) ([]smilecdr.ModuleOption, error) {
+	var moduleOptions []smilecdr.ModuleOption
+	for _, option := range options {
+		v, ok := moduleOptionOk(d, option)
+		if option.source != "" && v == nil {
+			script, sourceOk, err := expandScriptSource(d, option.scriptSource())
+			if err != nil {
+				return nil, err
+			}
+			if sourceOk {
+				v, ok = script, true
+			} else if d.HasChange(option.source) {
+				v, ok = nil, true // the source was removed, reset the option
+			}
+		}
+		if !ok && option.isSet() && option.defaultValue != nil && d.Id() == "" {
+			v, ok = option.defaultValue, true
+		}
+		if ok && v == nil && option.defaultValue != nil {
+			v = option.defaultValue // the attribute was removed, reset the option to its default
+		}
+		if ok {
+			moduleOptions = append(moduleOptions, smilecdr.ModuleOption{
+				Key:   option.key,
+				Value: option.format(v),
+			})
+		}
+	}
+	return moduleOptions, nil
+}
+
+// flattenModuleOptions sets the attributes of the resource from the options of the module configuration.
+// Attributes of options missing from the configuration keep their value.
+func flattenModuleOptions(d *schema.ResourceData, options []moduleOption, moduleConfig smilecdr.ModuleConfig) {
+	for _, option := range options {
+		value, ok := moduleConfig.LookupOptionOk(option.key)
+		if !ok {
+			continue
+		}
+		if option.source != "" {
+			flattenScriptSource(d, option.scriptSource(), value)
+			continue
+		}
+		if v, ok := option.parse(value); ok {
+			d.Set(option.attribute, v)
+		}
+	}
+}
+
+// moduleOptionData is what moduleOptionOk reads of a module resource, from its plan or at apply time.
+type moduleOptionData interface {
+	Id() string
+	Get(key string) interface{}
+	GetOk(key string) (interface{}, bool)
+	HasChange(key string) bool
+	GetRawConfig() cty.Value
+	GetRawState() cty.Value
+}
+
+// moduleOptionOk takes the place of d.GetOk for the options of the module resources, which must tell an
+// attribute left out of the configuration apart from one set to false or 0:
+//   - an attribute set in the configuration is sent, whatever its value;
+//   - an attribute held by the prior state but left out of the configuration, even at false or 0, is
+//     returned as nil: expandModuleOptions resets the option to its default, or sends it blank when it
+//     has none, which resets it to the default of the server;
+//   - any other attribute left out of it is sent when it is not empty, such as a default on create or a
+//     set read from the server.
+func moduleOptionOk(d moduleOptionData, option moduleOption) (interface{}, bool) {
+	raw := d.GetRawConfig()
+	if raw.IsNull() || !raw.Type().HasAttribute(option.attribute) {
+		return d.GetOk(option.attribute)
+	}
+
+	if !raw.GetAttr(option.attribute).IsNull() {
+		return d.Get(option.attribute), true
+	}
+	computed := option.isSet() && option.defaultValue != nil
+	if !computed && d.Id() != "" && rawAttributeSet(d.GetRawState(), option.attribute) {
+		return nil, true
+	}
+	if v, ok := d.GetOk(option.attribute); ok {
+		return v, true
+	}
+	if d.HasChange(option.attribute) {
+		return nil, true
+	}
+	return nil, false
+}
+
+// rawAttributeSet reports whether a raw configuration or state holds a value for the attribute.
+func rawAttributeSet(raw cty.Value, attribute string) bool {
+	return !raw.IsNull() && raw.Type().HasAttribute(attribute) && !raw.GetAttr(attribute).IsNull()
+}
+
+// moduleOptionsStateUpgrader upgrades the state of a module resource from the schema version in which
+// the attributes of some options had other types, given by attribute: it parses their values again as
+// the values of the options, such as a space-separated string as a set.
+func moduleOptionsStateUpgrader(version int, r *schema.Resource, options []moduleOption, previousTypes map[string]cty.Type) schema.StateUpgrader {
+	attributeTypes := r.CoreConfigSchema().ImpliedType().AttributeTypes()
+	for attribute, t := range previousTypes {
+		attributeTypes[attribute] = t
+	}
+
+	return schema.StateUpgrader{
+		Version: version,
+		Type:    cty.Object(attributeTypes),
+		Upgrade: func(ctx context.Context, rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+			for _, option := range options {
+				if _, ok := previousTypes[option.attribute]; !ok {
+					continue
+				}
+				switch value := rawState[option.attribute].(type) {
+				case string:
+					set, _ := option.parse(value)
+					rawState[option.attribute] = set.(*schema.Set).List()
+				case []interface{}:
+					set, _ := option.parse(option.format(value))
+					rawState[option.attribute] = set.(*schema.Set).List()
+				}
+			}
+			return rawState, nil
+		},
+	}
+}
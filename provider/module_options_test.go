@@ -0,0 +1,111 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+)
+
+var testModuleOptionTables = map[string][]moduleOption{
+	"smilecdr_smart_outbound_security": smartOutboundOptions,
+	"smilecdr_smart_inbound_security":  smartInboundOptions,
+}
+
+func TestModuleOptionTables(t *testing.T) {
+	for name, options := range testModuleOptionTables {
+		attributes := map[string]bool{}
+		keys := map[string]bool{}
+		for _, option := range options {
+			if attributes[option.attribute] || keys[option.key] {
+				t.Errorf("%s: %s (%s) is mapped twice", name, option.attribute, option.key)
+			}
+			attributes[option.attribute] = true
+			keys[option.key] = true
+
+			if option.minimumVersion != "" {
+				if _, err := smilecdr.ParseVersion(option.minimumVersion); err != nil {
+					t.Errorf("%s: %s: %s", name, option.attribute, err)
+				}
+			}
+		}
+	}
+}
+
+// TestModuleOptionsRoundTrip sets every option of a table, and checks that the module configuration
+// expanded from them flattens back to the same values.
+func TestModuleOptionsRoundTrip(t *testing.T) {
+	for name, options := range testModuleOptionTables {
+		r := Provider().ResourcesMap[name]
+
+		raw := map[string]interface{}{"module_id": "module"}
+		for i, option := range options {
+			raw[option.attribute] = testModuleOptionValue(option, i)
+		}
+		d := schema.TestResourceDataRaw(t, r.Schema, raw)
+
+		expanded, err := expandModuleOptions(d, options)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		moduleConfig := smilecdr.ModuleConfig{Options: expanded}
+		if len(moduleConfig.Options) != len(options) {
+			t.Errorf("%s: expanded %d options out of %d", name, len(moduleConfig.Options), len(options))
+		}
+
+		flattened := r.TestResourceData()
+		flattenModuleOptions(flattened, options, moduleConfig)
+		for _, option := range options {
+			expected, actual := d.Get(option.attribute), flattened.Get(option.attribute)
+			if set, ok := expected.(*schema.Set); ok {
+				if !set.Equal(actual) {
+					t.Errorf("%s: %s (%s) is %v after a round trip, expected %v", name, option.attribute, option.key, actual.(*schema.Set).List(), set.List())
+				}
+				continue
+			}
+			if !reflect.DeepEqual(expected, actual) {
+				t.Errorf("%s: %s (%s) is %#v after a round trip, expected %#v", name, option.attribute, option.key, actual, expected)
+			}
+		}
+	}
+}
+
+func testModuleOptionValue(option moduleOption, i int) interface{} {
+	switch option.optionType {
+	case optionBool:
+		return true
+	case optionInt:
+		return i + 1
+	case optionList, optionCommaList, optionSpaceList, optionSet, optionCommaSet, optionSpaceSet:
+		return []interface{}{fmt.Sprintf("first-%d", i), fmt.Sprintf("second-%d", i)}
+	}
+	return fmt.Sprintf("value-%d", i)
+}
+
+func TestModuleOptionParse(t *testing.T) {
+	tests := []struct {
+		option   moduleOption
+		value    string
+		expected interface{}
+		ok       bool
+	}{
+		{moduleOption{optionType: optionBool}, "false", false, true},
+		{moduleOption{optionType: optionBool}, "", false, false},
+		{moduleOption{optionType: optionInt}, "0", 0, true},
+		{moduleOption{optionType: optionInt}, "", 0, false},
+		{moduleOption{optionType: optionList}, "launch-ehr\nclient-public\n", []interface{}{"launch-ehr", "client-public"}, true},
+		{moduleOption{optionType: optionSpaceList}, "openid  profile", []interface{}{"openid", "profile"}, true},
+		{moduleOption{optionType: optionCommaList}, "a, b", []interface{}{"a", "b"}, true},
+	}
+	for _, test := range tests {
+		actual, ok := test.option.parse(test.value)
+		if ok != test.ok || (ok && !reflect.DeepEqual(actual, test.expected)) {
+			t.Errorf("parsing %q: got %#v, %t", test.value, actual, ok)
+		}
+	}
+}
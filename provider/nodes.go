@@ -0,0 +1,227 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+)
+
+const defaultNodeId = "Master"
+
+func nodeIdSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		Computed:    true,
+		Description: "The node ID of the node to be configured. Defaults to the `default_node_id` of the provider.",
+	}
+}
+
+func nodeIdsSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:          schema.TypeSet,
+		Optional:      true,
+		Elem:          &schema.Schema{Type: schema.TypeString},
+		ConflictsWith: []string{"node_id"},
+		Description:   "The node IDs of every node to configure the module on, with the same configuration. A node whose module configuration differs from the others is updated on the next apply. When set, `node_id` is the first of these nodes, in alphabetical order.",
+	}
+}
+
+func providerDefaultNodeId(m interface{}) string {
+	if c, ok := m.(*smilecdr.Client); ok && c != nil && c.DefaultNodeId != "" {
+		return c.DefaultNodeId
+	}
+	return defaultNodeId
+}
+
+func sortedStrings(set *schema.Set) []string {
+	values := make([]string, 0, set.Len())
+	for _, v := range set.List() {
+		values = append(values, v.(string))
+	}
+	sort.Strings(values)
+	return values
+}
+
+// defaultNodeIdDiff fills in a `node_id` left out of the configuration: the first of the `node_ids` of
+// a module, or else the default node of the provider for a new resource. An existing resource keeps
+// the node it was created on.
+func defaultNodeIdDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	raw := d.GetRawConfig()
+	if raw.IsNull() || !raw.GetAttr("node_id").IsNull() {
+		return nil
+	}
+
+	var nodeId string
+	if raw.Type().HasAttribute("node_ids") {
+		if !d.NewValueKnown("node_ids") {
+			return nil
+		}
+		if nodeIds := sortedStrings(d.Get("node_ids").(*schema.Set)); len(nodeIds) > 0 {
+			nodeId = nodeIds[0]
+		}
+	}
+	if nodeId == "" {
+		if d.Id() != "" {
+			return nil
+		}
+		nodeId = providerDefaultNodeId(m)
+	}
+
+	if d.Get("node_id").(string) != nodeId || !d.NewValueKnown("node_id") {
+		return d.SetNew("node_id", nodeId)
+	}
+	return nil
+}
+
+// moduleNodeIds returns the nodes a module is configured on: its `node_ids` when set, otherwise its `node_id`.
+func moduleNodeIds(d *schema.ResourceData) []string {
+	if nodeIds := sortedStrings(d.Get("node_ids").(*schema.Set)); len(nodeIds) > 0 {
+		return nodeIds
+	}
+	return []string{d.Get("node_id").(string)}
+}
+
+func previousModuleNodeIds(d *schema.ResourceData) map[string]bool {
+	oldNodeId, _ := d.GetChange("node_id")
+	oldNodeIds, _ := d.GetChange("node_ids")
+
+	previous := map[string]bool{}
+	for _, nodeId := range sortedStrings(oldNodeIds.(*schema.Set)) {
+		previous[nodeId] = true
+	}
+	if len(previous) == 0 && oldNodeId.(string) != "" {
+		previous[oldNodeId.(string)] = true
+	}
+	return previous
+}
+
+// createModuleConfig creates the module on every node it targets.
+func createModuleConfig(ctx context.Context, c *smilecdr.Client, d *schema.ResourceData, moduleConfig smilecdr.ModuleConfig) error {
+	for _, nodeId := range moduleNodeIds(d) {
+		if _, err := c.PostModuleConfig(ctx, nodeId, moduleConfig); err != nil {
+			return fmt.Errorf("node %s: %w", nodeId, err)
+		}
+	}
+	return nil
+}
+
+// updateModuleConfig sets the module on every node it targets, creating it on new nodes, and archives
+// it from the nodes no longer targeted. It returns the warnings of archiving the module.
+func updateModuleConfig(ctx context.Context, c *smilecdr.Client, d *schema.ResourceData, moduleConfig smilecdr.ModuleConfig) (diag.Diagnostics, error) {
+	previous := previousModuleNodeIds(d)
+	current := map[string]bool{}
+
+	for _, nodeId := range moduleNodeIds(d) {
+		current[nodeId] = true
+
+		exists := previous[nodeId]
+		if !exists {
+			// A node left out of the state by a refresh may still hold a diverging copy of the module.
+			_, err := c.GetModuleConfig(ctx, nodeId, moduleConfig.ModuleId)
+			if err != nil && !smilecdr.IsNotFound(err) {
+				return nil, fmt.Errorf("node %s: %w", nodeId, err)
+			}
+			exists = err == nil
+		}
+
+		var err error
+		if exists {
+			_, err = c.PutModuleConfig(ctx, nodeId, moduleConfig)
+		} else {
+			_, err = c.PostModuleConfig(ctx, nodeId, moduleConfig)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("node %s: %w", nodeId, err)
+		}
+	}
+
+	var diags diag.Diagnostics
+	for nodeId := range previous {
+		if current[nodeId] {
+			continue
+		}
+		warnings, err := archiveModule(ctx, c, d, nodeId)
+		if err != nil && !smilecdr.IsNotFound(err) {
+			return diags, fmt.Errorf("node %s: %w", nodeId, err)
+		}
+		diags = append(diags, warnings...)
+	}
+	return diags, nil
+}
+
+// readModuleConfig reads the module from its first node. The other nodes of `node_ids` are compared with
+// it, and those missing the module or holding a different configuration are left out of the state, so
+// that the next plan rolls the configuration out to them again.
+func readModuleConfig(ctx context.Context, c *smilecdr.Client, d *schema.ResourceData) (smilecdr.ModuleConfig, error) {
+	nodeIds := moduleNodeIds(d)
+	moduleId := d.Get("module_id").(string)
+
+	moduleConfig, err := c.GetModuleConfig(ctx, nodeIds[0], moduleId)
+	if err != nil {
+		return moduleConfig, err
+	}
+	if d.Get("node_ids").(*schema.Set).Len() == 0 {
+		return moduleConfig, nil
+	}
+
+	consistent := []interface{}{nodeIds[0]}
+	for _, nodeId := range nodeIds[1:] {
+		other, err := c.GetModuleConfig(ctx, nodeId, moduleId)
+		if smilecdr.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return moduleConfig, fmt.Errorf("node %s: %w", nodeId, err)
+		}
+		if sameModuleConfig(moduleConfig, other) {
+			consistent = append(consistent, nodeId)
+		}
+	}
+	d.Set("node_ids", consistent)
+
+	return moduleConfig, nil
+}
+
+// deleteModuleConfig archives the module from every node it targets. It returns the warnings of
+// archiving the module.
+func deleteModuleConfig(ctx context.Context, c *smilecdr.Client, d *schema.ResourceData) (diag.Diagnostics, error) {
+	var diags diag.Diagnostics
+	for _, nodeId := range moduleNodeIds(d) {
+		warnings, err := archiveModule(ctx, c, d, nodeId)
+		if err != nil && !smilecdr.IsNotFound(err) {
+			return diags, fmt.Errorf("node %s: %w", nodeId, err)
+		}
+		diags = append(diags, warnings...)
+	}
+	return diags, nil
+}
+
+func sameModuleConfig(a smilecdr.ModuleConfig, b smilecdr.ModuleConfig) bool {
+	options := func(m smilecdr.ModuleConfig) map[string]string {
+		values := map[string]string{}
+		for _, option := range m.Options {
+			values[option.Key] = option.Value
+		}
+		return values
+	}
+	dependencies := func(m smilecdr.ModuleConfig) map[smilecdr.ModuleDependency]bool {
+		values := map[smilecdr.ModuleDependency]bool{}
+		for _, dependency := range m.Dependencies {
+			values[dependency] = true
+		}
+		return values
+	}
+
+	return a.ModuleType == b.ModuleType &&
+		reflect.DeepEqual(options(a), options(b)) &&
+		reflect.DeepEqual(dependencies(a), dependencies(b))
+}
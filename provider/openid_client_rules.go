@@ -0,0 +1,99 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// openIdClientRule is a rule between attributes of an OpenID client that Smile CDR would only enforce
+// when a user signs in, if at all. A rule is skipped while any attribute it reads is not known.
+type openIdClientRule struct {
+	attribute  string   // the attribute the error is reported on
+	attributes []string // the attributes the rule reads
+	check      func(d *schema.ResourceDiff) string
+}
+
+var openIdClientRules = []openIdClientRule{
+	{
+		attribute:  "client_secrets",
+		attributes: []string{"secret_required", "client_secrets"},
+		check: func(d *schema.ResourceDiff) string {
+			if d.Get("secret_required").(bool) && d.Get("client_secrets").(*schema.Set).Len() == 0 {
+				return "secret_required is true, but the client has no client_secrets; add a client_secrets block, or set secret_required = false for a public client"
+			}
+			return ""
+		},
+	},
+	{
+		attribute:  "registered_redirect_uris",
+		attributes: []string{"allowed_grant_types", "registered_redirect_uris"},
+		check: func(d *schema.ResourceDiff) string {
+			if hasGrantType(d, "AUTHORIZATION_CODE") && d.Get("registered_redirect_uris").(*schema.Set).Len() == 0 {
+				return "allowed_grant_types includes AUTHORIZATION_CODE, but the client has no registered_redirect_uris to send the authorization code to"
+			}
+			return ""
+		},
+	},
+	{
+		attribute:  "jwks_url",
+		attributes: []string{"allowed_grant_types", "jwks_url", "public_jwks"},
+		check: func(d *schema.ResourceDiff) string {
+			if hasGrantType(d, "JWT_BEARER") && d.Get("jwks_url").(string) == "" && d.Get("public_jwks").(string) == "" {
+				return "allowed_grant_types includes JWT_BEARER, but the client has neither jwks_url nor public_jwks holding the keys that verify its signed assertions"
+			}
+			return ""
+		},
+	},
+	{
+		attribute:  "refresh_token_validity_seconds",
+		attributes: []string{"allowed_grant_types", "refresh_token_validity_seconds"},
+		check: func(d *schema.ResourceDiff) string {
+			if !hasGrantType(d, "REFRESH_TOKEN") {
+				return ""
+			}
+			// The attribute has a default, so whether it is set is told from the configuration.
+			raw := d.GetRawConfig()
+			if !raw.IsNull() && raw.Type().HasAttribute("refresh_token_validity_seconds") && raw.GetAttr("refresh_token_validity_seconds").IsNull() {
+				return "allowed_grant_types includes REFRESH_TOKEN, but refresh_token_validity_seconds is not set; set how long the refresh tokens of the client are valid, e.g. 86400 for a day"
+			}
+			if d.Get("refresh_token_validity_seconds").(int) <= 0 {
+				return fmt.Sprintf("allowed_grant_types includes REFRESH_TOKEN, but refresh_token_validity_seconds is %d; refresh tokens must be valid for at least a second", d.Get("refresh_token_validity_seconds").(int))
+			}
+			return ""
+		},
+	},
+}
+
+// openIdClientRulesDiff checks the rules between the attributes of an OpenID client at plan time, and
+// reports every rule broken.
+func openIdClientRulesDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	var errs []string
+	for _, rule := range openIdClientRules {
+		known := true
+		for _, attribute := range rule.attributes {
+			known = known && d.NewValueKnown(attribute)
+		}
+		if !known {
+			continue
+		}
+		if message := rule.check(d); message != "" {
+			errs = append(errs, fmt.Sprintf("%s: %s", rule.attribute, message))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+func hasGrantType(d *schema.ResourceDiff, grantType string) bool {
+	return d.Get("allowed_grant_types").(*schema.Set).Contains(grantType)
+}
@@ -0,0 +1,93 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zedwerks/terraform-smilecdr/provider/helper/validations"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+)
+
+// The scopes of an OpenID client that Smile CDR approves or grants without asking the user, which must
+// be scopes of the client.
+var openIdClientScopeSubsets = []string{"auto_approve_scopes", "auto_grant_scopes"}
+
+// openIdClientScopesDiff checks at plan time that the scopes approved or granted automatically are scopes
+// of the client. Wildcard system scopes of a public client are reported by the apply, as a plan cannot
+// warn, see publicClientWildcardScopesWarning.
+func openIdClientScopesDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if !d.NewValueKnown("scopes") {
+		return nil
+	}
+	scopes := d.Get("scopes").(*schema.Set)
+
+	var errs []string
+	for _, attribute := range openIdClientScopeSubsets {
+		if !d.NewValueKnown(attribute) {
+			continue
+		}
+		var missing []string
+		for _, scope := range d.Get(attribute).(*schema.Set).List() {
+			if !scopes.Contains(scope) {
+				missing = append(missing, scope.(string))
+			}
+		}
+		if len(missing) > 0 {
+			sort.Strings(missing)
+			errs = append(errs, fmt.Sprintf("%s: %s must also be in scopes, the client cannot be given a scope it does not have", attribute, strings.Join(missing, ", ")))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// isPublicOpenIdClient tells whether a client does not authenticate, with neither a secret nor a key.
+func isPublicOpenIdClient(secretRequired bool, jwksUrl string, publicJwks string) bool {
+	return !secretRequired && jwksUrl == "" && publicJwks == ""
+}
+
+// publicClientWildcardScopes returns the wildcard system scopes of a public client, which let anyone
+// holding its client ID access every resource of the server.
+func publicClientWildcardScopes(public bool, scopes []string) []string {
+	if !public {
+		return nil
+	}
+	var wildcards []string
+	for _, scope := range scopes {
+		if validations.IsWildcardSystemScope(scope) {
+			wildcards = append(wildcards, scope)
+		}
+	}
+	sort.Strings(wildcards)
+	return wildcards
+}
+
+func publicClientWildcardScopesDetail(clientId string, wildcards []string) string {
+	return fmt.Sprintf("The public client %s is granted the wildcard system scopes %s, giving access to every resource of the server without authenticating the client. Require a secret or a key with secret_required, jwks_url or public_jwks, or grant scopes of the resource types the client needs.", clientId, strings.Join(wildcards, ", "))
+}
+
+func publicClientWildcardScopesWarning(client *smilecdr.OpenIdClient) diag.Diagnostics {
+	public := isPublicOpenIdClient(smilecdr.BoolValue(client.SecretRequired), client.JwksUrl, client.PublicJwks)
+	wildcards := publicClientWildcardScopes(public, client.Scopes)
+	if len(wildcards) == 0 {
+		return nil
+	}
+
+	return diag.Diagnostics{
+		diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Wildcard system scopes granted to a public client",
+			Detail:   publicClientWildcardScopesDetail(client.ClientId, wildcards),
+		},
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zedwerks/terraform-smilecdr/provider/helper/validations"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+)
+
+const localSecurityModuleType = "SECURITY_IN_LOCAL"
+
+// Module options of a local inbound security module holding its password strength rules.
+var passwordPolicyOptions = map[string]func(*validations.PasswordPolicy) *int{
+	"password_strength.min_length":    func(p *validations.PasswordPolicy) *int { return &p.MinLength },
+	"password_strength.min_lowercase": func(p *validations.PasswordPolicy) *int { return &p.MinLowercase },
+	"password_strength.min_uppercase": func(p *validations.PasswordPolicy) *int { return &p.MinUppercase },
+	"password_strength.min_digits":    func(p *validations.PasswordPolicy) *int { return &p.MinDigits },
+	"password_strength.min_special":   func(p *validations.PasswordPolicy) *int { return &p.MinSpecial },
+}
+
+func passwordPolicyFromModuleConfig(moduleConfig smilecdr.ModuleConfig) validations.PasswordPolicy {
+	var policy validations.PasswordPolicy
+
+	for key, field := range passwordPolicyOptions {
+		val, ok := moduleConfig.LookupOptionOk(key)
+		if !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(val); err == nil {
+			*field(&policy) = n
+		}
+	}
+
+	return policy
+}
+
+// validatePasswordDiff checks a new or changed password against the password policy of the target
+// module. Only local inbound security modules store passwords, so any other module is skipped, as is
+// a server that cannot be reached at plan time.
+func validatePasswordDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if d.Id() != "" && !d.HasChange("password") {
+		return nil
+	}
+	if !d.NewValueKnown("password") || !d.NewValueKnown("node_id") || !d.NewValueKnown("module_id") {
+		return nil
+	}
+	c, ok := m.(*smilecdr.Client)
+	if !ok || c == nil {
+		return nil
+	}
+
+	password := d.Get("password").(string)
+	nodeId := d.Get("node_id").(string)
+	moduleId := d.Get("module_id").(string)
+
+	moduleConfig, err := c.GetModuleConfig(ctx, nodeId, moduleId)
+	if err != nil {
+		tflog.Warn(ctx, "Unable to read the password policy of module "+moduleId+": "+err.Error())
+		return nil
+	}
+	if moduleConfig.ModuleType != localSecurityModuleType {
+		return nil
+	}
+
+	violations := validations.PasswordPolicyViolations(passwordPolicyFromModuleConfig(moduleConfig), password)
+	if len(violations) > 0 {
+		return validations.FormatPasswordPolicyViolations("password", moduleId, violations)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/zedwerks/terraform-smilecdr/provider/helper/validations"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr/smilecdrtest"
+)
+
+func TestPasswordPolicyViolations(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   validations.PasswordPolicy
+		password string
+		expected []string
+	}{
+		{"no rules", validations.PasswordPolicy{}, "", nil},
+		{"length met", validations.PasswordPolicy{MinLength: 8}, "abcdefgh", nil},
+		{"length", validations.PasswordPolicy{MinLength: 8}, "abcdefg", []string{"must contain at least 8 characters, found 7"}},
+		{"length counts runes", validations.PasswordPolicy{MinLength: 4}, "éèêë", nil},
+		{"uppercase met", validations.PasswordPolicy{MinUppercase: 2}, "ABc", nil},
+		{"uppercase", validations.PasswordPolicy{MinUppercase: 2}, "Abc", []string{"must contain at least 2 uppercase letters, found 1"}},
+		{"lowercase met", validations.PasswordPolicy{MinLowercase: 2}, "aBc", nil},
+		{"lowercase", validations.PasswordPolicy{MinLowercase: 2}, "ABc", []string{"must contain at least 2 lowercase letters, found 1"}},
+		{"digits met", validations.PasswordPolicy{MinDigits: 2}, "a12", nil},
+		{"digits", validations.PasswordPolicy{MinDigits: 2}, "a1b", []string{"must contain at least 2 digits, found 1"}},
+		{"special met", validations.PasswordPolicy{MinSpecial: 2}, "a!#", nil},
+		{"special", validations.PasswordPolicy{MinSpecial: 2}, "a!b", []string{"must contain at least 2 special characters, found 1"}},
+		{"spaces are not special", validations.PasswordPolicy{MinSpecial: 1}, "a b", []string{"must contain at least 1 special characters, found 0"}},
+		{"every rule", validations.PasswordPolicy{MinLength: 12, MinLowercase: 1, MinUppercase: 1, MinDigits: 1, MinSpecial: 1}, "password", []string{
+			"must contain at least 12 characters, found 8",
+			"must contain at least 1 uppercase letters, found 0",
+			"must contain at least 1 digits, found 0",
+			"must contain at least 1 special characters, found 0",
+		}},
+	}
+
+	for _, test := range tests {
+		if got := validations.PasswordPolicyViolations(test.policy, test.password); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%s: %q broke %q, expected %q", test.name, test.password, got, test.expected)
+		}
+	}
+}
+
+func TestPasswordPolicyDiff(t *testing.T) {
+	server := smilecdrtest.NewServer()
+	c := smilecdr.NewClient(context.Background(), server.Start(), "admin", "password")
+	defer server.Close()
+
+	module := smilecdr.ModuleConfig{ModuleId: "local_security", ModuleType: localSecurityModuleType}
+	module.SetOption("password_strength.min_length", "10")
+	module.SetOption("password_strength.min_uppercase", "1")
+	module.SetOption("password_strength.min_lowercase", "1")
+	module.SetOption("password_strength.min_digits", "2")
+	module.SetOption("password_strength.min_special", "1")
+	server.Modules["Master/local_security"] = module
+
+	r := resourceUser()
+	plan := func(password string) error {
+		_, err := r.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(map[string]interface{}{
+			"node_id":  "Master",
+			"username": "service-account",
+			"password": password,
+		}), c)
+		return err
+	}
+
+	expected := "password does not meet the password policy of module local_security:\n" +
+		"  - must contain at least 10 characters, found 8\n" +
+		"  - must contain at least 2 digits, found 1\n" +
+		"  - must contain at least 1 special characters, found 0"
+	if err := plan("Passw0rd"); err == nil || err.Error() != expected {
+		t.Errorf("a weak password plans with %v, expected %q", err, expected)
+	}
+	if err := plan("Passw0rd!42"); err != nil {
+		t.Errorf("a strong password does not plan: %s", err)
+	}
+
+	// Only local security modules hold passwords.
+	module.ModuleType = "SECURITY_IN_LDAP"
+	server.Modules["Master/local_security"] = module
+	if err := plan("Passw0rd"); err != nil {
+		t.Errorf("the password is checked against a module that holds none: %s", err)
+	}
+}
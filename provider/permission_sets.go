@@ -0,0 +1,202 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// authority is a permission/argument pair, shared by users, clients and permission sets.
+type authority struct {
+	Permission string `json:"permission"`
+	Argument   string `json:"argument,omitempty"`
+}
+
+func (a authority) String() string {
+	if a.Argument == "" {
+		return a.Permission
+	}
+	return fmt.Sprintf("%s(%s)", a.Permission, a.Argument)
+}
+
+// permissionSetDefinition is the JSON document exported by smilecdr_permission_set as 'definition',
+// and attached to users and clients through their 'permission_sets' attribute.
+type permissionSetDefinition struct {
+	Name        string      `json:"name"`
+	Authorities []authority `json:"authorities"`
+}
+
+func permissionSetsSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeSet,
+		Optional:    true,
+		Elem:        &schema.Schema{Type: schema.TypeString},
+		Description: "The 'definition' of each smilecdr_permission_set to attach. Their permissions are granted in addition to those listed directly.",
+	}
+}
+
+func effectiveAuthoritiesSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Computed:    true,
+		Description: "Every permission held on the server, along with the permission sets that granted it. Permissions granted directly have no permission sets.",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"permission": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"argument": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"granted_by": {
+					Type:     schema.TypeList,
+					Computed: true,
+					Elem:     &schema.Schema{Type: schema.TypeString},
+				},
+			},
+		},
+	}
+}
+
+func encodePermissionSet(name string, authorities []authority) (string, error) {
+	sorted := append([]authority{}, authorities...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].String() < sorted[j].String()
+	})
+
+	definition, err := json.Marshal(permissionSetDefinition{Name: name, Authorities: sorted})
+	if err != nil {
+		return "", err
+	}
+	return string(definition), nil
+}
+
+func decodePermissionSets(d *schema.ResourceData) ([]permissionSetDefinition, error) {
+	var sets []permissionSetDefinition
+
+	for _, v := range d.Get("permission_sets").(*schema.Set).List() {
+		var set permissionSetDefinition
+		if err := json.Unmarshal([]byte(v.(string)), &set); err != nil {
+			return nil, fmt.Errorf("permission_sets: expected the 'definition' of a smilecdr_permission_set: %s", err)
+		}
+		sets = append(sets, set)
+	}
+	sort.Slice(sets, func(i, j int) bool {
+		return sets[i].Name < sets[j].Name
+	})
+
+	return sets, nil
+}
+
+func authoritiesFromSet(authorities *schema.Set) []authority {
+	var result []authority
+
+	for _, v := range authorities.List() {
+		s := v.(map[string]interface{})
+		if s["permission"] != nil && s["permission"].(string) != "" {
+			result = append(result, authority{
+				Permission: s["permission"].(string),
+				Argument:   s["argument"].(string),
+			})
+		}
+	}
+
+	return result
+}
+
+func flattenAuthorities(authorities []authority) []interface{} {
+	result := make([]interface{}, len(authorities))
+
+	for i, a := range authorities {
+		result[i] = map[string]interface{}{
+			"permission": a.Permission,
+			"argument":   a.Argument,
+		}
+	}
+
+	return result
+}
+
+// expandPermissionSets adds the permissions of every attached set to the directly granted ones.
+func expandPermissionSets(direct []authority, sets []permissionSetDefinition) []authority {
+	seen := map[authority]bool{}
+	var result []authority
+
+	add := func(a authority) {
+		if !seen[a] {
+			seen[a] = true
+			result = append(result, a)
+		}
+	}
+	for _, a := range direct {
+		add(a)
+	}
+	for _, set := range sets {
+		for _, a := range set.Authorities {
+			add(a)
+		}
+	}
+
+	return result
+}
+
+// attributePermissionSets splits the permissions held on the server into those to report as directly granted,
+// and those granted by an attached set. Permissions that are neither configured directly nor part of an
+// attached set are reported as unmanaged.
+func attributePermissionSets(server []authority, configured []authority, sets []permissionSetDefinition) (direct []authority, effective []interface{}, unmanaged []authority) {
+	isConfigured := map[authority]bool{}
+	for _, a := range configured {
+		isConfigured[a] = true
+	}
+
+	for _, a := range server {
+		grantedBy := []interface{}{}
+		for _, set := range sets {
+			for _, s := range set.Authorities {
+				if s == a {
+					grantedBy = append(grantedBy, set.Name)
+					break
+				}
+			}
+		}
+
+		if isConfigured[a] || len(grantedBy) == 0 {
+			direct = append(direct, a)
+		}
+		if !isConfigured[a] && len(grantedBy) == 0 {
+			unmanaged = append(unmanaged, a)
+		}
+
+		effective = append(effective, map[string]interface{}{
+			"permission": a.Permission,
+			"argument":   a.Argument,
+			"granted_by": grantedBy,
+		})
+	}
+
+	return direct, effective, unmanaged
+}
+
+func unmanagedAuthoritiesWarning(unmanaged []authority) diag.Diagnostics {
+	names := make([]string, len(unmanaged))
+	for i, a := range unmanaged {
+		names[i] = a.String()
+	}
+
+	return diag.Diagnostics{
+		diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Permissions found that are not granted by any attached permission set",
+			Detail:   fmt.Sprintf("The following permissions were added outside of Terraform and will be removed on the next apply: %s", strings.Join(names, ", ")),
+		},
+	}
+}
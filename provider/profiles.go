@@ -0,0 +1,74 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"errors"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+)
+
+// selectProfile reads the profile selected by the `profile` argument from the profiles file. Without a
+// selected profile, the `default` profile is used if the file exists and has one.
+func selectProfile(d *schema.ResourceData) (smilecdr.Profile, diag.Diagnostics) {
+	name := d.Get("profile").(string)
+	path := firstNonEmpty(d.Get("profiles_file").(string), smilecdr.DefaultProfilesPath())
+
+	profiles, err := smilecdr.ReadProfiles(path)
+	if name == "" && errors.Is(err, fs.ErrNotExist) {
+		return smilecdr.Profile{}, nil
+	}
+	if err != nil {
+		return smilecdr.Profile{}, diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  "Unable to read the Smile CDR profiles file",
+			Detail:   err.Error(),
+		}}
+	}
+
+	if name == "" {
+		return profiles[smilecdr.DefaultProfileName], nil
+	}
+	profile, ok := profiles[name]
+	if !ok {
+		names := make([]string, 0, len(profiles))
+		for n := range profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return profile, diag.Errorf("profile %q not found in %s, the profiles are: %s", name, path, strings.Join(names, ", "))
+	}
+	return profile, nil
+}
+
+func missingCredentials(config smilecdr.ClientConfig) []string {
+	var missing []string
+	if config.AuthMethod == smilecdr.AuthMethodBearer {
+		if config.Token == "" {
+			missing = append(missing, "a token")
+		}
+		return missing
+	}
+	if config.Username == "" {
+		missing = append(missing, "a username")
+	}
+	if config.Password == "" {
+		missing = append(missing, "a password")
+	}
+	return missing
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
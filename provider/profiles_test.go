@@ -0,0 +1,109 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+)
+
+func TestProviderProfiles(t *testing.T) {
+	for _, env := range []string{"SMILECDR_PROFILE", "SMILECDR_PROFILES_FILE", "SMILECDR_BASE_URL", "SMILECDR_AUTH_METHOD", "SMILECDR_USERNAME", "SMILECDR_PASSWORD", "SMILECDR_TOKEN", "SMILECDR_CA_CERT_FILE", "SMILECDR_NODE_ID"} {
+		t.Setenv(env, "")
+	}
+
+	path := filepath.Join(t.TempDir(), "config")
+	err := os.WriteFile(path, []byte(`# Smile CDR environments
+[default]
+base_url = http://localhost:9000
+username = admin
+password = password
+
+[test]
+base_url = https://test.example.org:9000
+auth_method = bearer
+token = t0ken
+default_node_id = Node2
+
+[incomplete]
+base_url = https://prod.example.org:9000
+username = admin
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configure := func(raw map[string]interface{}) (*smilecdr.Client, string) {
+		raw["profiles_file"] = path
+		p := Provider()
+		diags := p.Configure(context.Background(), terraform.NewResourceConfigRaw(raw))
+		if diags.HasError() {
+			var summaries []string
+			for _, d := range diags {
+				summaries = append(summaries, d.Summary+": "+d.Detail)
+			}
+			return nil, strings.Join(summaries, "\n")
+		}
+		return p.Meta().(*smilecdr.Client), ""
+	}
+
+	if c, errs := configure(map[string]interface{}{}); c == nil || c.DefaultNodeId != "Master" {
+		t.Errorf("expected the default profile, got %s", errs)
+	}
+	if c, errs := configure(map[string]interface{}{"profile": "test"}); c == nil || c.DefaultNodeId != "Node2" {
+		t.Errorf("expected the test profile, got %s", errs)
+	}
+	if c, errs := configure(map[string]interface{}{"profile": "test", "default_node_id": "Node3"}); c == nil || c.DefaultNodeId != "Node3" {
+		t.Errorf("expected the argument to take precedence over the profile, got %s", errs)
+	}
+
+	// An explicit false verifies the certificate that the profile does not.
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(f, "\n[insecure]\nbase_url = %s\nusername = admin\npassword = password\ntls_insecure_skip_verify = true\n", server.URL)
+	f.Close()
+	for _, test := range []struct {
+		raw      map[string]interface{}
+		verified bool
+	}{
+		{map[string]interface{}{"profile": "insecure"}, false},
+		{map[string]interface{}{"profile": "insecure", "tls_insecure_skip_verify": false}, true},
+		{map[string]interface{}{"profile": "insecure", "tls_insecure_skip_verify": true}, false},
+	} {
+		c, errs := configure(test.raw)
+		if c == nil {
+			t.Fatalf("expected the insecure profile, got %s", errs)
+		}
+		_, err := c.GetNodes(context.Background())
+		if verified := err != nil && strings.Contains(err.Error(), "certificate"); verified != test.verified {
+			t.Errorf("with %v, expected the certificate to be verified: %t, got %v", test.raw, test.verified, err)
+		}
+	}
+
+	t.Setenv("SMILECDR_PROFILE", "incomplete")
+	if _, errs := configure(map[string]interface{}{}); !strings.Contains(errs, "needs a password") {
+		t.Errorf("expected a missing password error, got %q", errs)
+	}
+	t.Setenv("SMILECDR_PASSWORD", "password")
+	if c, errs := configure(map[string]interface{}{}); c == nil {
+		t.Errorf("expected the environment to complete the profile, got %s", errs)
+	}
+
+	if _, errs := configure(map[string]interface{}{"profile": "prod"}); !strings.Contains(errs, `profile "prod" not found`) {
+		t.Errorf("expected a missing profile error, got %q", errs)
+	}
+}
@@ -5,23 +5,45 @@ package provider
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zedwerks/terraform-smilecdr/provider/helper/validations"
 	"github.com/zedwerks/terraform-smilecdr/smilecdr"
 )
 
 func Provider() *schema.Provider {
 	return &schema.Provider{
 		Schema: map[string]*schema.Schema{
+			"profile": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("SMILECDR_PROFILE", nil),
+				Description: "The profile of the profiles file to read connection settings from. Arguments and their environment variables take precedence over the profile. Defaults to the `SMILECDR_PROFILE` environment variable, or the `default` profile when the file has one.",
+			},
+			"profiles_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("SMILECDR_PROFILES_FILE", nil),
+				Description: "The path of the profiles file. Defaults to the `SMILECDR_PROFILES_FILE` environment variable, or `~/.smilecdr/config`.",
+			},
 			"base_url": {
 				Type:         schema.TypeString,
 				Optional:     true,
-				Default:      "http://localhost:9000",
 				DefaultFunc:  schema.EnvDefaultFunc("SMILECDR_BASE_URL", nil),
 				ValidateFunc: validation.IsURLWithHTTPorHTTPS,
+				Description:  "The base URL of the Admin JSON API. Defaults to the `SMILECDR_BASE_URL` environment variable, the profile, or `http://localhost:9000`.",
+			},
+			"auth_method": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				DefaultFunc:      schema.EnvDefaultFunc("SMILECDR_AUTH_METHOD", nil),
+				ValidateDiagFunc: validations.ValidateDiagFunc(validation.StringInSlice([]string{smilecdr.AuthMethodBasic, smilecdr.AuthMethodBearer}, false)),
+				Description:      "How to authenticate: 'basic' with `username` and `password`, or 'bearer' with a `token`. Defaults to the `SMILECDR_AUTH_METHOD` environment variable, the profile, or 'basic'.",
 			},
 			"username": {
 				Type:        schema.TypeString,
@@ -34,6 +56,51 @@ func Provider() *schema.Provider {
 				Sensitive:   true,
 				DefaultFunc: schema.EnvDefaultFunc("SMILECDR_PASSWORD", nil),
 			},
+			"token": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc("SMILECDR_TOKEN", nil),
+				Description: "The bearer token of the 'bearer' auth method. Defaults to the `SMILECDR_TOKEN` environment variable, or the profile.",
+			},
+			"ca_cert_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("SMILECDR_CA_CERT_FILE", nil),
+				Description: "A PEM file of certificate authorities to trust, in addition to the system ones, e.g. for a server with a certificate from an internal CA. Defaults to the `SMILECDR_CA_CERT_FILE` environment variable, or the profile.",
+			},
+			"tls_insecure_skip_verify": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Do not verify the certificate of the server. Only for test servers. Defaults to the profile; `false` here verifies the certificate even when the profile skips it.",
+			},
+			"default_node_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("SMILECDR_NODE_ID", nil),
+				Description: "The node of resources that do not set a `node_id`. Defaults to the `SMILECDR_NODE_ID` environment variable, the profile, or `Master`.",
+			},
+			"permission_catalog": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Default:          "builtin",
+				ValidateDiagFunc: validations.ValidateDiagFunc(validation.StringInSlice([]string{"builtin", "server"}, false)),
+				Description:      "The catalog used to validate user and client permissions. Use 'server' to validate against the permissions of the connected Smile CDR server, falling back to the built-in catalog when the server catalog cannot be loaded.",
+			},
+			"server_version": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				DefaultFunc:      schema.EnvDefaultFunc("SMILECDR_SERVER_VERSION", nil),
+				ValidateDiagFunc: validateServerVersion,
+				Description:      "The Smile CDR version of the server, e.g. `2024.05.R01`, used instead of the version detected from the server. Defaults to the `SMILECDR_SERVER_VERSION` environment variable.",
+			},
+			"unsupported_options": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Default:          "warn",
+				ValidateDiagFunc: validations.ValidateDiagFunc(validation.StringInSlice([]string{"warn", "error"}, false)),
+				Description:      "What to do with options that the server version does not support: 'warn' logs them at plan time and reports a warning when the resource is created or updated, 'error' fails the plan. Options are not checked when the server version is unknown.",
+			},
 		},
 		ResourcesMap: map[string]*schema.Resource{
 			"smilecdr_openid_client":            resourceOpenIdClient(),
@@ -42,28 +109,58 @@ func Provider() *schema.Provider {
 			"smilecdr_smart_inbound_security":   resourceSmartInboundSecurity(),
 			"smilecdr_module_config":            resourceModuleConfig(),
 			"smilecdr_user":                     resourceUser(),
+			"smilecdr_permission_set":           resourcePermissionSet(),
+			"smilecdr_user_authorities":         resourceUserAuthorities(),
+		},
+		DataSourcesMap: map[string]*schema.Resource{
+			"smilecdr_permissions": dataSourcePermissions(),
+			"smilecdr_nodes":       dataSourceNodes(),
 		},
-		DataSourcesMap:       map[string]*schema.Resource{},
 		ConfigureContextFunc: providerConfigure,
 	}
 }
 
 func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
 
-	var diags diag.Diagnostics
-	var baseUrl string
+	profile, diags := selectProfile(d)
+	if diags.HasError() {
+		return nil, diags
+	}
 
-	username := d.Get("username").(string)
-	password := d.Get("password").(string)
-	baseUrl = d.Get("base_url").(string)
+	config := smilecdr.ClientConfig{
+		BaseUrl:               firstNonEmpty(d.Get("base_url").(string), profile.BaseUrl, "http://localhost:9000"),
+		AuthMethod:            firstNonEmpty(d.Get("auth_method").(string), profile.AuthMethod, smilecdr.AuthMethodBasic),
+		Username:              firstNonEmpty(d.Get("username").(string), profile.Username),
+		Password:              firstNonEmpty(d.Get("password").(string), profile.Password),
+		Token:                 firstNonEmpty(d.Get("token").(string), profile.Token),
+		CACertFile:            firstNonEmpty(d.Get("ca_cert_file").(string), profile.CACertFile),
+		TLSInsecureSkipVerify: profile.TLSInsecureSkipVerify,
+	}
+	// An explicit false turns off the verification skipped by the profile, so the argument is only
+	// left to the profile when it is not set at all.
+	if skipVerify, ok := d.GetOkExists("tls_insecure_skip_verify"); ok {
+		config.TLSInsecureSkipVerify = skipVerify.(bool)
+	}
 
-	if (baseUrl != "") && (username != "") && (password != "") {
-		c := smilecdr.NewClient(ctx, baseUrl, username, password)
+	if missing := missingCredentials(config); len(missing) > 0 {
+		return nil, append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Missing Smile CDR credentials",
+			Detail: fmt.Sprintf("The %s auth method needs %s. Set the provider arguments, their SMILECDR_* environment variables, or select a profile with them.",
+				config.AuthMethod, strings.Join(missing, " and ")),
+		})
+	}
 
-		return c, diags
+	c, err := smilecdr.NewClientFromConfig(ctx, config)
+	if err != nil {
+		return nil, append(diags, diag.FromErr(err)...)
 	}
+	c.ServerPermissionCatalog = d.Get("permission_catalog").(string) == "server"
+	c.DefaultNodeId = firstNonEmpty(d.Get("default_node_id").(string), profile.DefaultNodeId, defaultNodeId)
+	c.RejectUnsupportedOptions = d.Get("unsupported_options").(string) == "error"
+	c.ServerVersion = serverVersion(ctx, c, d.Get("server_version").(string))
 
-	return nil, diags
+	return c, diags
 }
 
 func suppressSensitiveDataDiff(k, old, new string, d *schema.ResourceData) (bool, error) {
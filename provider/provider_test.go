@@ -1,10 +1,16 @@
 package provider
 
 import (
+	"context"
+	"encoding/json"
 	"os"
+	"sort"
+	"strings"
 	"testing"
 
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 var testAccProviders map[string]*schema.Provider
@@ -43,3 +49,97 @@ func testAccPreCheck(t *testing.T) {
 		t.Fatal("SMILECDR_PASSWORD must be set for acceptance tests")
 	}
 }
+
+// testApply plans the configuration of a resource against its state and applies the plan, as Terraform
+// would, without the Terraform CLI.
+func testApply(t *testing.T, r *schema.Resource, state *terraform.InstanceState, config map[string]interface{}, meta interface{}) *terraform.InstanceState {
+	t.Helper()
+	ctx := context.Background()
+
+	diff, err := r.Diff(ctx, state, terraform.NewResourceConfigRaw(config), meta)
+	if err != nil {
+		t.Fatalf("plan failed: %s", err)
+	}
+	if diff == nil {
+		return state
+	}
+	newState, diags := r.Apply(ctx, state, diff, meta)
+	if diags.HasError() {
+		t.Fatalf("apply failed: %+v", diags)
+	}
+	return newState
+}
+
+// testOptionalBools lists the boolean attributes of a resource that can be set in its configuration.
+func testOptionalBools(r *schema.Resource) []string {
+	var attributes []string
+	for attribute, s := range r.Schema {
+		if s.Type == schema.TypeBool && s.Optional {
+			attributes = append(attributes, attribute)
+		}
+	}
+	sort.Strings(attributes)
+	return attributes
+}
+
+// testJSONField returns a field of an Admin API object as it is sent on the wire, by the snake case name
+// of its attribute, and whether the field is present.
+func testJSONField(t *testing.T, object interface{}, attribute string) (interface{}, bool) {
+	t.Helper()
+	body, err := json.Marshal(object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		t.Fatal(err)
+	}
+
+	words := strings.Split(attribute, "_")
+	for i := 1; i < len(words); i++ {
+		words[i] = strings.ToUpper(words[i][:1]) + words[i][1:]
+	}
+	value, ok := fields[strings.Join(words, "")]
+	return value, ok
+}
+
+// isEmptyJSONField reports whether a field read by testJSONField holds no value.
+func isEmptyJSONField(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// testRawConfigState returns an empty state holding the raw configuration of the top-level attributes of
+// primitive types, which Terraform passes to the plan and the legacy Diff does not. Other attributes are null.
+func testRawConfigState(r *schema.Resource, config map[string]interface{}) *terraform.InstanceState {
+	attributeTypes := r.CoreConfigSchema().ImpliedType().AttributeTypes()
+	values := map[string]cty.Value{}
+	for attribute, t := range attributeTypes {
+		values[attribute] = cty.NullVal(t)
+		switch value := config[attribute].(type) {
+		case string:
+			values[attribute] = cty.StringVal(value)
+		case int:
+			values[attribute] = cty.NumberIntVal(int64(value))
+		case bool:
+			values[attribute] = cty.BoolVal(value)
+		}
+	}
+	return &terraform.InstanceState{RawConfig: cty.ObjectVal(values)}
+}
+
+// testRawUpdateState returns a copy of the state of a resource, to update it with a configuration, holding the
+// raw configuration and the raw prior state that Terraform passes to the plan and the legacy Diff does not.
+func testRawUpdateState(r *schema.Resource, state *terraform.InstanceState, config map[string]interface{}) *terraform.InstanceState {
+	updated := state.DeepCopy()
+	updated.RawConfig = testRawConfigState(r, config).RawConfig
+	updated.RawState, _ = state.AttrsAsObjectValue(r.CoreConfigSchema().ImpliedType())
+	return updated
+}
@@ -25,12 +25,15 @@ func resourceModuleConfig() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: resourceModuleConfigImport,
 		},
+		CustomizeDiff: customizeDiffAll(
+			defaultNodeIdDiff,
+			validateModuleDependenciesDiff([]string{"dependencies"}, dependenciesListDiff),
+		),
 		Schema: map[string]*schema.Schema{
-			"node_id": {
-				Type:     schema.TypeString,
-				Optional: true,
-				Default:  "Master",
-			},
+			"force_archive":         forceArchiveSchema(),
+			"repoint_dependents_to": repointDependentsToSchema(),
+			"node_id":               nodeIdSchema(),
+			"node_ids":              nodeIdsSchema(),
 			"module_id": {
 				Type:     schema.TypeString,
 				Required: true,
@@ -62,8 +65,9 @@ func resourceModuleConfig() *schema.Resource {
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"module_id": {
-							Type:     schema.TypeString,
-							Required: true,
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "The ID of the module depended on. It is checked at plan time: a module that exists on the node, or on each of the `node_ids`, must have a module type that fits `type`, and not depend back on this module. A module that does not exist yet is expected to be created by the same apply.",
 						},
 						"type": {
 							Type:     schema.TypeString,
@@ -107,19 +111,18 @@ func resourceModuleConfigCreate(ctx context.Context, d *schema.ResourceData, m i
 	c := m.(*smilecdr.Client)
 
 	moduleConfig, mErr := resourceToModuleConfig(d)
-	nodeId := d.Get("node_id").(string)
 
 	if mErr != nil {
 		return diag.FromErr(mErr)
 	}
 
-	_, err := c.PostModuleConfig(ctx, nodeId, *moduleConfig)
+	err := createModuleConfig(ctx, c, d, *moduleConfig)
 
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	fmt.Printf("Successfully created module config: %s/%s\n", nodeId, moduleConfig.ModuleId)
+	fmt.Printf("Successfully created module config: %s/%s\n", d.Get("node_id").(string), moduleConfig.ModuleId)
 
 	d.SetId(moduleConfig.ModuleId) // the primary resource identifier. must be unique.
 
@@ -129,10 +132,11 @@ func resourceModuleConfigCreate(ctx context.Context, d *schema.ResourceData, m i
 func resourceModuleConfigRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	c := m.(*smilecdr.Client)
 
-	moduleId := d.Get("module_id").(string)
-	nodeId := d.Get("node_id").(string)
-
-	moduleConfig, err := c.GetModuleConfig(ctx, nodeId, moduleId)
+	moduleConfig, err := readModuleConfig(ctx, c, d)
+	if smilecdr.IsNotFound(err) {
+		d.SetId("") // removed outside of Terraform
+		return nil
+	}
 
 	if err != nil {
 		diags := diag.FromErr(err)
@@ -171,7 +175,6 @@ func resourceModuleConfigUpdate(ctx context.Context, d *schema.ResourceData, m i
 	c := m.(*smilecdr.Client)
 
 	moduleConfig, mErr := resourceToModuleConfig(d)
-	nodeId := d.Get("node_id").(string)
 
 	if mErr != nil {
 		return diag.FromErr(mErr)
@@ -179,10 +182,10 @@ func resourceModuleConfigUpdate(ctx context.Context, d *schema.ResourceData, m i
 
 	d.SetId(moduleConfig.ModuleId)
 
-	_, err := c.PutModuleConfig(ctx, nodeId, *moduleConfig)
+	warnings, err := updateModuleConfig(ctx, c, d, *moduleConfig)
 
 	if err != nil {
-		diags := diag.FromErr(err)
+		diags := append(warnings, diag.FromErr(err)...)
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Warning,
 			Summary:  "Error updating module config",
@@ -190,19 +193,16 @@ func resourceModuleConfigUpdate(ctx context.Context, d *schema.ResourceData, m i
 		return diags
 	}
 
-	return resourceModuleConfigRead(ctx, d, m)
+	return append(warnings, resourceModuleConfigRead(ctx, d, m)...)
 }
 
 func resourceModuleConfigDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	c := m.(*smilecdr.Client)
 
-	moduleId := d.Get("module_id").(string)
-	nodeId := d.Get("node_id").(string)
-
-	err := c.DeleteModuleConfig(ctx, nodeId, moduleId)
+	warnings, err := deleteModuleConfig(ctx, c, d)
 
 	if err != nil {
-		diags := diag.FromErr(err)
+		diags := append(warnings, diag.FromErr(err)...)
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Warning,
 			Summary:  "Error deleting module config",
@@ -211,7 +211,7 @@ func resourceModuleConfigDelete(ctx context.Context, d *schema.ResourceData, m i
 	}
 	d.SetId("") // This is unset when the resource is deleted
 
-	return nil
+	return warnings
 }
 
 func resourceModuleConfigImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
@@ -229,6 +229,7 @@ func resourceModuleConfigImport(ctx context.Context, d *schema.ResourceData, met
 
 	d.Set("node_id", parts[0])
 	d.Set("module_id", parts[1])
+	d.Set("force_archive", false)
 
 	diagnostics := resourceModuleConfigRead(ctx, d, meta)
 	if diagnostics.HasError() {
@@ -0,0 +1,266 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr/smilecdrtest"
+)
+
+func TestModuleConfigDependencyValidation(t *testing.T) {
+	moduleName := "smart_" + acctest.RandString(8)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config:      testModuleConfigDependencyConfig(moduleName, "admin_json", "SECURITY_IN_UP"),
+				ExpectError: regexp.MustCompile(`module admin_json is a ADMIN_JSON module on node Master, expected one of SECURITY_IN_LOCAL`),
+			},
+		},
+	})
+}
+
+func testModuleConfigDependencyConfig(moduleName string, dependency string, dependencyType string) string {
+
+	return fmt.Sprintf(`resource "smilecdr_module_config" "testacc" {
+		module_id   = "%s"
+		module_type = "SECURITY_OUT_SMART"
+
+		options {
+			key   = "issuer.url"
+			value = "http://localhost:9200"
+		}
+
+		dependencies {
+			module_id = "%s"
+			type      = "%s"
+		}
+	}`, moduleName, dependency, dependencyType)
+}
+
+func TestModuleConfigArchiveWithDependents(t *testing.T) {
+	securityName := "security_" + acctest.RandString(8)
+	smartName := "smart_" + acctest.RandString(8)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testModuleConfigSecurityConfig(securityName, false),
+			},
+			{
+				Config: testModuleConfigSecurityConfig(securityName, false) + testModuleConfigDependencyConfig(smartName, securityName, "SECURITY_IN_UP"),
+			},
+			{
+				// Destroying the security module alone must not take the SMART module down.
+				Config:      testModuleConfigDependencyConfig(smartName, securityName, "SECURITY_IN_UP"),
+				ExpectError: regexp.MustCompile(fmt.Sprintf(`module %s cannot be archived, these modules depend on it: %s`, securityName, smartName)),
+			},
+			{
+				// Lets the test clean up, in whichever order the modules are destroyed.
+				Config: testModuleConfigSecurityConfig(securityName, true) + testModuleConfigDependencyConfig(smartName, securityName, "SECURITY_IN_UP"),
+			},
+		},
+	})
+}
+
+func testModuleConfigSecurityConfig(moduleName string, forceArchive bool) string {
+
+	return fmt.Sprintf(`resource "smilecdr_module_config" "security" {
+		module_id     = "%s"
+		module_type   = "SECURITY_IN_LOCAL"
+		force_archive = %t
+
+		options {
+			key   = "password_strength.min_length"
+			value = "8"
+		}
+
+		dependencies {
+			module_id = "persistence"
+			type      = "PERSISTENCE_ALL"
+		}
+	}
+	`, moduleName, forceArchive)
+}
+
+func TestModuleDependencyCycle(t *testing.T) {
+	modules := map[string]smilecdr.ModuleConfig{
+		"fhir_endpoint":  {ModuleId: "fhir_endpoint", Dependencies: []smilecdr.ModuleDependency{{ModuleId: "persistence"}, {ModuleId: "smart_auth"}}},
+		"smart_auth":     {ModuleId: "smart_auth", Dependencies: []smilecdr.ModuleDependency{{ModuleId: "local_security"}}},
+		"persistence":    {ModuleId: "persistence"},
+		"local_security": {ModuleId: "local_security"},
+	}
+
+	if cycle := dependencyCycle(modules, "smart_auth", "local_security"); cycle != nil {
+		t.Errorf("unexpected cycle %v", cycle)
+	}
+	if cycle := dependencyCycle(modules, "local_security", "fhir_endpoint"); strings.Join(cycle, " -> ") != "local_security -> fhir_endpoint -> smart_auth -> local_security" {
+		t.Errorf("unexpected cycle %v", cycle)
+	}
+}
+
+func TestModuleDependenciesDiff(t *testing.T) {
+	server := smilecdrtest.NewServer()
+	c := smilecdr.NewClient(context.Background(), server.Start(), "admin", "password")
+	defer server.Close()
+
+	server.Modules["Master/local_security"] = smilecdr.ModuleConfig{ModuleId: "local_security", ModuleType: "SECURITY_IN_LOCAL"}
+	server.Modules["Master/admin_json"] = smilecdr.ModuleConfig{ModuleId: "admin_json", ModuleType: "ADMIN_JSON"}
+	server.Modules["Master/fhir_endpoint"] = smilecdr.ModuleConfig{ModuleId: "fhir_endpoint", ModuleType: "ENDPOINT_FHIR_REST_R4",
+		Dependencies: []smilecdr.ModuleDependency{{ModuleId: "smart_auth", Type: "SECURITY_OUT_SMART"}}}
+
+	r := resourceModuleConfig()
+	plan := func(dependencies ...[2]string) error {
+		var list []interface{}
+		for _, dependency := range dependencies {
+			list = append(list, map[string]interface{}{"module_id": dependency[0], "type": dependency[1]})
+		}
+		_, err := r.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(map[string]interface{}{
+			"node_id":      "Master",
+			"module_id":    "smart_auth",
+			"module_type":  "SECURITY_OUT_SMART",
+			"options":      []interface{}{map[string]interface{}{"key": "issuer.url", "value": "https://smart.example.org"}},
+			"dependencies": list,
+		}), c)
+		return err
+	}
+
+	if err := plan([2]string{"local_security", "SECURITY_IN_UP"}); err != nil {
+		t.Errorf("an existing module of a fitting type does not plan: %s", err)
+	}
+	if err := plan([2]string{"new_security", "SECURITY_IN_UP"}); err != nil {
+		t.Errorf("a module created by the same apply does not plan: %s", err)
+	}
+
+	// A single error is reported on its attribute, as Terraform only attaches a cty.PathError to one.
+	err := plan([2]string{"admin_json", "SECURITY_IN_UP"})
+	pathErr, ok := err.(cty.PathError)
+	if !ok || !pathErr.Path.Equals(cty.GetAttrPath("dependencies").IndexInt(0).GetAttr("module_id")) {
+		t.Fatalf("a module of the wrong type is reported as %#v", err)
+	}
+	if expected := "module admin_json is a ADMIN_JSON module on node Master, expected one of SECURITY_IN_LOCAL, SECURITY_IN_LDAP, SECURITY_IN_SCRIPTED"; pathErr.Error() != expected {
+		t.Errorf("a module of the wrong type is reported as %q, expected %q", pathErr.Error(), expected)
+	}
+
+	err = plan([2]string{"admin_json", "SECURITY_IN_UP"}, [2]string{"fhir_endpoint", "ENDPOINT_FHIR_REST_R4"}, [2]string{"smart_auth", "SECURITY_OUT_SMART"})
+	expected := "dependencies.2.module_id: module smart_auth cannot depend on itself\n" +
+		"dependencies.0.module_id: module admin_json is a ADMIN_JSON module on node Master, expected one of SECURITY_IN_LOCAL, SECURITY_IN_LDAP, SECURITY_IN_SCRIPTED\n" +
+		"dependencies.1.module_id: depending on module fhir_endpoint creates a cycle on node Master: smart_auth -> fhir_endpoint -> smart_auth"
+	if err == nil || err.Error() != expected {
+		t.Errorf("several errors are reported as %v, expected %q", err, expected)
+	}
+}
+
+func TestModuleConfigArchive(t *testing.T) {
+	setup := func(t *testing.T, config map[string]interface{}) (*smilecdrtest.Server, *smilecdr.Client, *terraform.InstanceState) {
+		server := smilecdrtest.NewServer()
+		c := smilecdr.NewClient(context.Background(), server.Start(), "admin", "password")
+		t.Cleanup(server.Close)
+
+		server.Modules["Master/persistence"] = smilecdr.ModuleConfig{ModuleId: "persistence", ModuleType: "PERSISTENCE_R4"}
+		server.Modules["Master/new_security"] = smilecdr.ModuleConfig{ModuleId: "new_security", ModuleType: "SECURITY_IN_LOCAL"}
+		state := testApply(t, resourceModuleConfig(), nil, config, c)
+		for _, id := range []string{"smart_b", "smart_a"} {
+			server.Modules["Master/"+id] = smilecdr.ModuleConfig{ModuleId: id, ModuleType: "SECURITY_OUT_SMART",
+				Dependencies: []smilecdr.ModuleDependency{{ModuleId: "security", Type: "SECURITY_IN_UP"}}}
+		}
+		return server, c, state
+	}
+	config := func(attributes map[string]interface{}) map[string]interface{} {
+		config := map[string]interface{}{
+			"node_id":      "Master",
+			"module_id":    "security",
+			"module_type":  "SECURITY_IN_LOCAL",
+			"options":      []interface{}{map[string]interface{}{"key": "password_strength.min_length", "value": "8"}},
+			"dependencies": []interface{}{map[string]interface{}{"module_id": "persistence", "type": "PERSISTENCE_ALL"}},
+		}
+		for key, value := range attributes {
+			config[key] = value
+		}
+		return config
+	}
+	destroy := func(c *smilecdr.Client, state *terraform.InstanceState) diag.Diagnostics {
+		_, diags := resourceModuleConfig().Apply(context.Background(), state, &terraform.InstanceDiff{Destroy: true}, c)
+		return diags
+	}
+
+	t.Run("refuse", func(t *testing.T) {
+		server, c, state := setup(t, config(nil))
+		diags := destroy(c, state)
+		expected := "node Master: module security cannot be archived, these modules depend on it: smart_a, smart_b."
+		if !diags.HasError() || !strings.HasPrefix(diags[0].Summary, expected) {
+			t.Errorf("destroying a module with dependents gives %+v, expected %q", diags, expected)
+		}
+		if _, exists := server.Modules["Master/security"]; !exists {
+			t.Error("a module with dependents was archived")
+		}
+	})
+
+	t.Run("force_archive", func(t *testing.T) {
+		server, c, state := setup(t, config(map[string]interface{}{"force_archive": true}))
+		if diags := destroy(c, state); diags.HasError() {
+			t.Fatalf("destroy failed: %+v", diags)
+		}
+		if _, exists := server.Modules["Master/security"]; exists {
+			t.Error("force_archive did not archive the module")
+		}
+		if server.Modules["Master/smart_a"].Dependencies[0].ModuleId != "security" {
+			t.Error("force_archive changed the dependents")
+		}
+	})
+
+	t.Run("repoint", func(t *testing.T) {
+		server, c, state := setup(t, config(map[string]interface{}{"repoint_dependents_to": "new_security"}))
+		server.Requests = nil
+		diags := destroy(c, state)
+		if diags.HasError() {
+			t.Fatalf("destroy failed: %+v", diags)
+		}
+		if len(diags) != 1 || diags[0].Severity != diag.Warning || !strings.HasSuffix(diags[0].Detail, "made to depend on new_security instead, and started again: smart_a, smart_b") {
+			t.Errorf("re-pointing warns with %+v", diags)
+		}
+		for _, id := range []string{"smart_a", "smart_b"} {
+			if dependency := server.Modules["Master/"+id].Dependencies[0]; dependency.ModuleId != "new_security" {
+				t.Errorf("%s depends on %s", id, dependency.ModuleId)
+			}
+		}
+		if _, exists := server.Modules["Master/security"]; exists {
+			t.Error("the re-pointed module was not archived")
+		}
+
+		var writes []string
+		for _, request := range server.Requests {
+			if !strings.HasPrefix(request, "GET ") {
+				writes = append(writes, request)
+			}
+		}
+		expected := []string{
+			"POST /module-config/Master/smart_a/stop",
+			"PUT /module-config/Master/smart_a/set",
+			"POST /module-config/Master/smart_a/start",
+			"POST /module-config/Master/smart_b/stop",
+			"PUT /module-config/Master/smart_b/set",
+			"POST /module-config/Master/smart_b/start",
+			"DELETE /module-config/Master/security/archive",
+		}
+		if strings.Join(writes, "\n") != strings.Join(expected, "\n") {
+			t.Errorf("re-pointing made the requests:\n%s", strings.Join(writes, "\n"))
+		}
+	})
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/zedwerks/terraform-smilecdr/provider/helper/validations"
@@ -26,6 +27,15 @@ func resourceOpenIdClient() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: resourceOpenIdClientImport,
 		},
+		CustomizeDiff: customdiff.All(
+			defaultNodeIdDiff,
+			validateAuthoritiesDiff("permissions"),
+			openIdClientScopesDiff,
+			openIdClientRulesDiff,
+			customdiff.ComputedIf("effective_permissions", func(ctx context.Context, d *schema.ResourceDiff, m interface{}) bool {
+				return d.HasChanges("permissions", "permission_sets")
+			}),
+		),
 		Schema: map[string]*schema.Schema{
 			"created": {
 				Type:     schema.TypeBool,
@@ -35,11 +45,7 @@ func resourceOpenIdClient() *schema.Resource {
 				Type:     schema.TypeInt,
 				Computed: true,
 			},
-			"node_id": {
-				Type:     schema.TypeString,
-				Optional: true,
-				Default:  "Master",
-			},
+			"node_id": nodeIdSchema(),
 			"module_id": {
 				Type:     schema.TypeString,
 				Optional: true,
@@ -83,17 +89,21 @@ func resourceOpenIdClient() *schema.Resource {
 				Default:  false,
 			},
 			"auto_approve_scopes": {
-				Type:     schema.TypeSet,
-				Optional: true,
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "The scopes approved without asking the user. They must also be in `scopes`.",
 				Elem: &schema.Schema{
-					Type: schema.TypeString,
+					Type:             schema.TypeString,
+					ValidateDiagFunc: validations.IsSmartScope,
 				},
 			},
 			"auto_grant_scopes": {
-				Type:     schema.TypeSet,
-				Optional: true,
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "The scopes granted to the client even when it does not request them. They must also be in `scopes`.",
 				Elem: &schema.Schema{
-					Type: schema.TypeString,
+					Type:             schema.TypeString,
+					ValidateDiagFunc: validations.IsSmartScope,
 				},
 			},
 			"can_introspect_any_tokens": {
@@ -126,6 +136,10 @@ func resourceOpenIdClient() *schema.Resource {
 							ValidateDiagFunc: validations.ValidateDiagFunc(validation.StringLenBetween(8, 256)),
 							DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
 								created := d.Get("created").(bool) || false
+								// A secret removed from the configuration is still a change, which clears it on the server.
+								if new == "" {
+									return false
+								}
 								// Suppress the output of changes to the 'secret' attribute in the plan
 								if created {
 									fmt.Printf("k = %s, old = %s, new = %s\n", k, old, new)
@@ -162,6 +176,7 @@ func resourceOpenIdClient() *schema.Resource {
 			"jwks_url": {
 				Type:             schema.TypeString,
 				Optional:         true,
+				Description:      "The URL of the JSON Web Key Set of the client. It, or `public_jwks`, is required with the `JWT_BEARER` grant type.",
 				ValidateDiagFunc: validations.ValidateDiagFunc(validation.IsURLWithHTTPorHTTPS),
 			},
 			"permissions": {
@@ -170,9 +185,8 @@ func resourceOpenIdClient() *schema.Resource {
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"permission": {
-							Type:             schema.TypeString,
-							Required:         true,
-							ValidateDiagFunc: validations.IsUserPermission,
+							Type:     schema.TypeString,
+							Required: true,
 						},
 						"argument": {
 							Type:     schema.TypeString,
@@ -181,21 +195,26 @@ func resourceOpenIdClient() *schema.Resource {
 					},
 				},
 			},
+			"permission_sets":       permissionSetsSchema(),
+			"effective_permissions": effectiveAuthoritiesSchema(),
 			"public_jwks": {
-				Type:     schema.TypeString,
-				Optional: true,
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The JSON Web Key Set of the client. It, or `jwks_url`, is required with the `JWT_BEARER` grant type.",
 			},
 			"refresh_token_validity_seconds": {
-				Type:     schema.TypeInt,
-				Optional: true,
-				Default:  86400,
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     86400,
+				Description: "How long the refresh tokens of the client are valid, in seconds. It must be set, to a positive number, with the `REFRESH_TOKEN` grant type.",
 			},
 			"registered_redirect_uris": {
-				Type:     schema.TypeSet,
-				Optional: true,
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "The URIs the client may be redirected to with an authorization code or token. They must use https, except on localhost and loopback addresses, and have no fragment. Required with the `AUTHORIZATION_CODE` grant type.",
 				Elem: &schema.Schema{
 					Type:             schema.TypeString,
-					ValidateDiagFunc: validations.ValidateDiagFunc(validation.IsURLWithHTTPorHTTPS),
+					ValidateDiagFunc: validations.IsRedirectURI,
 				},
 			},
 			"remember_approved_scopes": {
@@ -203,17 +222,22 @@ func resourceOpenIdClient() *schema.Resource {
 				Optional: true,
 			},
 			"scopes": {
-				Type:     schema.TypeSet,
-				Optional: true,
-				Elem:     &schema.Schema{Type: schema.TypeString},
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "The scopes the client may request: OpenID Connect scopes (`openid`, `profile`, `fhirUser`, ...), SMART launch scopes (`launch`, `launch/patient`, ...), or SMART v1 (`patient/*.read`) or v2 (`patient/Observation.rs?category=laboratory`) resource scopes. Resource types are checked against FHIR R4: scopes of the resource types added by FHIR R5 fail the plan. Wildcard system scopes such as `system/*.read` given to a public client, one with neither a secret nor a key, are reported with a warning when the client is created or updated.",
+				Elem: &schema.Schema{
+					Type:             schema.TypeString,
+					ValidateDiagFunc: validations.IsSmartScope,
+				},
 			},
 			"secret_client_can_change": {
 				Type:     schema.TypeBool,
 				Optional: true,
 			},
 			"secret_required": {
-				Type:     schema.TypeBool,
-				Optional: true,
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Whether the client must authenticate with one of its `client_secrets`, which it must then have.",
 			},
 			"archived_at": {
 				Type:             schema.TypeString,
@@ -240,17 +264,14 @@ func flattenClientSecrets(clientSecrets []smilecdr.ClientSecret) []interface{} {
 	return secrets
 }
 
-func flattenPermissions(permissions []smilecdr.UserPermission) []interface{} {
-	perms := make([]interface{}, len(permissions))
+func userPermissionsToAuthorities(permissions []smilecdr.UserPermission) []authority {
+	authorities := make([]authority, len(permissions))
 
 	for i, p := range permissions {
-		perms[i] = map[string]interface{}{
-			"permission": p.Permission,
-			"argument":   p.Argument,
-		}
+		authorities[i] = authority{Permission: p.Permission, Argument: p.Argument}
 	}
 
-	return perms
+	return authorities
 }
 
 func resourceDataToOpenIdClient(d *schema.ResourceData) (*smilecdr.OpenIdClient, error) {
@@ -274,18 +295,19 @@ func resourceDataToOpenIdClient(d *schema.ResourceData) (*smilecdr.OpenIdClient,
 		}
 	}
 
-	permissions := d.Get("permissions").(*schema.Set).List()
+	sets, err := decodePermissionSets(d)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions := expandPermissionSets(authoritiesFromSet(d.Get("permissions").(*schema.Set)), sets)
 
 	userPermissions := make([]smilecdr.UserPermission, 0)
-	for _, permission := range permissions {
-		s := permission.(map[string]interface{})
-		if s["permission"] != nil {
-			perm := smilecdr.UserPermission{
-				Permission: s["permission"].(string),
-				Argument:   s["argument"].(string),
-			}
-			userPermissions = append(userPermissions, perm)
-		}
+	for _, p := range permissions {
+		userPermissions = append(userPermissions, smilecdr.UserPermission{
+			Permission: p.Permission,
+			Argument:   p.Argument,
+		})
 	}
 
 	allowedGrantTypes := make([]string, 0)
@@ -334,28 +356,28 @@ func resourceDataToOpenIdClient(d *schema.ResourceData) (*smilecdr.OpenIdClient,
 		ModuleId:                    d.Get("module_id").(string),
 		ClientId:                    d.Get("client_id").(string),
 		ClientName:                  d.Get("client_name").(string),
-		Enabled:                     d.Get("enabled").(bool),
-		AccessTokenValiditySeconds:  d.Get("access_token_validity_seconds").(int),
+		Enabled:                     smilecdr.Bool(d.Get("enabled").(bool)),
+		AccessTokenValiditySeconds:  smilecdr.Int(d.Get("access_token_validity_seconds").(int)),
 		AllowedGrantTypes:           allowedGrantTypes,
-		AlwaysRequireApproval:       d.Get("always_require_approval").(bool),
-		AttestationAccepted:         d.Get("attestation_accepted").(bool),
+		AlwaysRequireApproval:       smilecdr.Bool(d.Get("always_require_approval").(bool)),
+		AttestationAccepted:         smilecdr.Bool(d.Get("attestation_accepted").(bool)),
 		AutoApproveScopes:           autoApproveScopes,
 		AutoGrantScopes:             autoGrantScopes,
-		CanIntrospectAnyTokens:      d.Get("can_introspect_any_tokens").(bool),
-		CanIntrospectOwnTokens:      d.Get("can_introspect_own_tokens").(bool),
-		CanReissueTokens:            d.Get("can_reissue_tokens").(bool),
+		CanIntrospectAnyTokens:      smilecdr.Bool(d.Get("can_introspect_any_tokens").(bool)),
+		CanIntrospectOwnTokens:      smilecdr.Bool(d.Get("can_introspect_own_tokens").(bool)),
+		CanReissueTokens:            smilecdr.Bool(d.Get("can_reissue_tokens").(bool)),
 		ClientSecrets:               clientSecrets,
-		CreatedByAppSphere:          d.Get("created_by_app_sphere").(bool),
-		FixedScope:                  d.Get("fixed_scope").(bool),
+		CreatedByAppSphere:          smilecdr.Bool(d.Get("created_by_app_sphere").(bool)),
+		FixedScope:                  smilecdr.Bool(d.Get("fixed_scope").(bool)),
 		JwksUrl:                     d.Get("jwks_url").(string),
 		Permissions:                 userPermissions,
 		PublicJwks:                  d.Get("public_jwks").(string),
-		RefreshTokenValiditySeconds: d.Get("refresh_token_validity_seconds").(int),
+		RefreshTokenValiditySeconds: smilecdr.Int(d.Get("refresh_token_validity_seconds").(int)),
 		RegisteredRedirectUris:      registeredRedirectUris,
-		RememberApprovedScopes:      d.Get("remember_approved_scopes").(bool),
+		RememberApprovedScopes:      smilecdr.Bool(d.Get("remember_approved_scopes").(bool)),
 		Scopes:                      scopes,
-		SecretClientCanChange:       d.Get("secret_client_can_change").(bool),
-		SecretRequired:              d.Get("secret_required").(bool),
+		SecretClientCanChange:       smilecdr.Bool(d.Get("secret_client_can_change").(bool)),
+		SecretRequired:              smilecdr.Bool(d.Get("secret_required").(bool)),
 		ArchivedAt:                  d.Get("archived_at").(string),
 	}
 
@@ -392,7 +414,7 @@ func resourceOpenIdClientCreate(ctx context.Context, d *schema.ResourceData, m i
 	d.SetId(client.ClientId) // the primary resource identifier. must be unique.
 	d.Set("pid", o.Pid)      // the pid is needed for Put requests
 
-	return resourceOpenIdClientRead(ctx, d, m)
+	return append(publicClientWildcardScopesWarning(client), resourceOpenIdClientRead(ctx, d, m)...)
 }
 
 func resourceOpenIdClientRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
@@ -408,6 +430,10 @@ func resourceOpenIdClientRead(ctx context.Context, d *schema.ResourceData, m int
 	moduleId := d.Get("module_id").(string)
 
 	openIdClient, err := c.GetOpenIdClient(ctx, nodeId, moduleId, client_id)
+	if smilecdr.IsNotFound(err) {
+		d.SetId("") // removed outside of Terraform
+		return nil
+	}
 
 	if err != nil {
 		diags := diag.FromErr(err)
@@ -418,6 +444,16 @@ func resourceOpenIdClientRead(ctx context.Context, d *schema.ResourceData, m int
 		return diags
 	}
 
+	sets, err := decodePermissionSets(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	configured := authoritiesFromSet(d.Get("permissions").(*schema.Set))
+	direct, effective, unmanaged := attributePermissionSets(userPermissionsToAuthorities(openIdClient.Permissions), configured, sets)
+	if len(sets) > 0 && len(unmanaged) > 0 {
+		diags = append(diags, unmanagedAuthoritiesWarning(unmanaged)...)
+	}
+
 	d.SetId(openIdClient.ClientId)
 
 	d.Set("pid", openIdClient.Pid)
@@ -425,28 +461,29 @@ func resourceOpenIdClientRead(ctx context.Context, d *schema.ResourceData, m int
 	d.Set("module_id", openIdClient.ModuleId)
 	d.Set("client_id", openIdClient.ClientId)
 	d.Set("client_name", openIdClient.ClientName)
-	d.Set("enabled", openIdClient.Enabled)
-	d.Set("access_token_validity_seconds", openIdClient.AccessTokenValiditySeconds)
+	d.Set("enabled", smilecdr.BoolValue(openIdClient.Enabled))
+	d.Set("access_token_validity_seconds", smilecdr.IntValue(openIdClient.AccessTokenValiditySeconds))
 	d.Set("allowed_grant_types", openIdClient.AllowedGrantTypes)
-	d.Set("always_require_approval", openIdClient.AlwaysRequireApproval)
-	d.Set("attestation_accepted", openIdClient.AttestationAccepted)
+	d.Set("always_require_approval", smilecdr.BoolValue(openIdClient.AlwaysRequireApproval))
+	d.Set("attestation_accepted", smilecdr.BoolValue(openIdClient.AttestationAccepted))
 	d.Set("auto_approve_scopes", openIdClient.AutoApproveScopes)
 	d.Set("auto_grant_scopes", openIdClient.AutoGrantScopes)
-	d.Set("can_introspect_any_tokens", openIdClient.CanIntrospectAnyTokens)
-	d.Set("can_introspect_own_tokens", openIdClient.CanIntrospectOwnTokens)
-	d.Set("can_reissue_tokens", openIdClient.CanReissueTokens)
+	d.Set("can_introspect_any_tokens", smilecdr.BoolValue(openIdClient.CanIntrospectAnyTokens))
+	d.Set("can_introspect_own_tokens", smilecdr.BoolValue(openIdClient.CanIntrospectOwnTokens))
+	d.Set("can_reissue_tokens", smilecdr.BoolValue(openIdClient.CanReissueTokens))
 	d.Set("client_secrets", flattenClientSecrets(openIdClient.ClientSecrets))
-	d.Set("created_by_app_sphere", openIdClient.CreatedByAppSphere)
-	d.Set("fixed_scope", openIdClient.FixedScope)
+	d.Set("created_by_app_sphere", smilecdr.BoolValue(openIdClient.CreatedByAppSphere))
+	d.Set("fixed_scope", smilecdr.BoolValue(openIdClient.FixedScope))
 	d.Set("jwks_url", openIdClient.JwksUrl)
-	d.Set("permissions", flattenPermissions(openIdClient.Permissions))
+	d.Set("permissions", flattenAuthorities(direct))
+	d.Set("effective_permissions", effective)
 	d.Set("public_jwks", openIdClient.PublicJwks)
-	d.Set("refresh_token_validity_seconds", openIdClient.RefreshTokenValiditySeconds)
+	d.Set("refresh_token_validity_seconds", smilecdr.IntValue(openIdClient.RefreshTokenValiditySeconds))
 	d.Set("registered_redirect_uris", openIdClient.RegisteredRedirectUris)
-	d.Set("remember_approved_scopes", openIdClient.RememberApprovedScopes)
+	d.Set("remember_approved_scopes", smilecdr.BoolValue(openIdClient.RememberApprovedScopes))
 	d.Set("scopes", openIdClient.Scopes)
-	d.Set("secret_client_can_change", openIdClient.SecretClientCanChange)
-	d.Set("secret_required", openIdClient.SecretRequired)
+	d.Set("secret_client_can_change", smilecdr.BoolValue(openIdClient.SecretClientCanChange))
+	d.Set("secret_required", smilecdr.BoolValue(openIdClient.SecretRequired))
 	d.Set("archived_at", openIdClient.ArchivedAt)
 	return diags
 
@@ -476,7 +513,7 @@ func resourceOpenIdClientUpdate(ctx context.Context, d *schema.ResourceData, m i
 		return diags
 	}
 
-	return resourceOpenIdClientRead(ctx, d, m)
+	return append(publicClientWildcardScopesWarning(client), resourceOpenIdClientRead(ctx, d, m)...)
 
 }
 
@@ -521,7 +558,7 @@ func resourceOpenIdClientImport(ctx context.Context, d *schema.ResourceData, met
 		return nil, fmt.Errorf("invalid import. supported import formats: {{nodeId}}/{{moduleId}}/{{clientId}}")
 	}
 
-	_, err := c.GetOpenIdClient(ctx, parts[0], parts[1], parts[3])
+	_, err := c.GetOpenIdClient(ctx, parts[0], parts[1], parts[2])
 	if err != nil {
 		return nil, err
 	}
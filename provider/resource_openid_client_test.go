@@ -4,12 +4,18 @@
 package provider
 
 import (
+	"context"
 	"fmt"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr/smilecdrtest"
 )
 
 func TestSmileCdrOpenIdClientBasic(t *testing.T) {
@@ -57,13 +63,14 @@ func testOpenIdClientConfig_basic() string {
   		client_name       = "Client1"
 		fixed_scope                    = false
 		refresh_token_validity_seconds = 86400
-		registered_redirect_uris       = ["http://example-client1.com:6000"]
+		registered_redirect_uris       = ["https://example-client1.com:6000"]
 		scopes = [
 			"openid",
 			"profile",
 			"fhirUser",
 			"patient/*.read",
 			"launch",
+			"launch/patient",
 			"offline_access"
 		]
 		permissions {
@@ -93,7 +100,7 @@ func testOpenIdClientConfig_confidential() string {
 		}
 		fixed_scope                    = false
 		refresh_token_validity_seconds = 86400
-		registered_redirect_uris       = ["http://example-client1.com:6000", "http://example-client1.com:6001"]
+		registered_redirect_uris       = ["https://example-client1.com:6000", "https://example-client1.com:6001"]
 		scopes = [
 			"openid",
 			"profile",
@@ -136,3 +143,212 @@ func testAccOpenIdClientExists(n string) resource.TestCheckFunc {
 		return nil
 	}
 }
+
+func TestOpenIdClientBooleans(t *testing.T) {
+	server := smilecdrtest.NewServer()
+	c := smilecdr.NewClient(context.Background(), server.Start(), "admin", "password")
+	defer server.Close()
+
+	r := resourceOpenIdClient()
+	config := map[string]interface{}{
+		"node_id":                  "Master",
+		"client_id":                "my-app",
+		"client_name":              "My App",
+		"allowed_grant_types":      []interface{}{"AUTHORIZATION_CODE"},
+		"registered_redirect_uris": []interface{}{"https://my-app.example.org/callback"},
+		"client_secrets":           []interface{}{map[string]interface{}{"secret": "my-app-secret"}},
+	}
+
+	var state *terraform.InstanceState
+	for _, attribute := range testOptionalBools(r) {
+		for _, value := range []bool{false, true, false} {
+			config[attribute] = value
+			state = testApply(t, r, state, config, c)
+
+			stored, ok := testJSONField(t, server.OpenIdClients[0], attribute)
+			if !ok || stored != value {
+				t.Errorf("%s = %t did not reach the server, which holds %v", attribute, value, stored)
+			}
+			if state.Attributes[attribute] != strconv.FormatBool(value) {
+				t.Errorf("%s = %t is %s in the state", attribute, value, state.Attributes[attribute])
+			}
+		}
+	}
+
+	for _, value := range []int{0, 600, 0} {
+		config["access_token_validity_seconds"] = value
+		state = testApply(t, r, state, config, c)
+		if stored := smilecdr.IntValue(server.OpenIdClients[0].AccessTokenValiditySeconds); stored != value || server.OpenIdClients[0].AccessTokenValiditySeconds == nil {
+			t.Errorf("access_token_validity_seconds = %d did not reach the server, which holds %d", value, stored)
+		}
+	}
+
+	// Strings and lists removed from the configuration are cleared on the server.
+	values := map[string]interface{}{
+		"jwks_url":            "https://my-app.example.org/jwks.json",
+		"public_jwks":         `{"keys":[]}`,
+		"scopes":              []interface{}{"openid", "profile"},
+		"auto_approve_scopes": []interface{}{"openid"},
+		"auto_grant_scopes":   []interface{}{"profile"},
+		"permissions":         []interface{}{map[string]interface{}{"permission": "FHIR_ALL_READ"}},
+	}
+	for attribute, value := range values {
+		config[attribute] = value
+	}
+	state = testApply(t, r, state, config, c)
+	for attribute := range values {
+		if stored, ok := testJSONField(t, server.OpenIdClients[0], attribute); !ok || isEmptyJSONField(stored) {
+			t.Errorf("%s did not reach the server, which holds %v", attribute, stored)
+		}
+	}
+
+	for attribute := range values {
+		delete(config, attribute)
+	}
+	config["allowed_grant_types"] = []interface{}{"CLIENT_CREDENTIALS"}
+	delete(config, "registered_redirect_uris")
+	delete(config, "client_secrets")
+	config["client_name"] = ""
+	state = testApply(t, r, state, config, c)
+	cleared := []string{"registered_redirect_uris", "client_secrets", "client_name"}
+	for attribute := range values {
+		cleared = append(cleared, attribute)
+	}
+	for _, attribute := range cleared {
+		if stored, _ := testJSONField(t, server.OpenIdClients[0], attribute); !isEmptyJSONField(stored) {
+			t.Errorf("%s removed from the configuration is still %v on the server", attribute, stored)
+		}
+	}
+	if diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), c); err != nil || (diff != nil && !diff.Empty()) {
+		t.Errorf("the cleared fields plan %v (%v)", diff, err)
+	}
+}
+
+func TestOpenIdClientScopes(t *testing.T) {
+	server := smilecdrtest.NewServer()
+	c := smilecdr.NewClient(context.Background(), server.Start(), "admin", "password")
+	defer server.Close()
+
+	r := resourceOpenIdClient()
+	config := map[string]interface{}{
+		"node_id":             "Master",
+		"client_id":           "my-app",
+		"client_name":         "My App",
+		"allowed_grant_types": []interface{}{"CLIENT_CREDENTIALS"},
+		"scopes":              []interface{}{"openid", "launch/patient", "patient/Observation.rs?category=laboratory"},
+		"auto_approve_scopes": []interface{}{"openid", "launch/patient"},
+		"auto_grant_scopes":   []interface{}{"openid", "offline_access", "patient/*.read"},
+	}
+	_, err := r.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(config), c)
+	if err == nil || err.Error() != "auto_grant_scopes: offline_access, patient/*.read must also be in scopes, the client cannot be given a scope it does not have" {
+		t.Errorf("auto_grant_scopes not in scopes plan with %v", err)
+	}
+
+	for attribute, scope := range map[string]string{
+		"scopes":              "patient/Observation.sr",
+		"auto_approve_scopes": "launch/location",
+		"auto_grant_scopes":   "user/*.reads",
+	} {
+		invalid := map[string]interface{}{}
+		for k, v := range config {
+			invalid[k] = v
+		}
+		invalid[attribute] = []interface{}{scope}
+		if diags := r.Validate(terraform.NewResourceConfigRaw(invalid)); !diags.HasError() {
+			t.Errorf("%s = [%q] is valid", attribute, scope)
+		}
+	}
+
+	// A public client granted wildcard system scopes is created with a warning, a confidential one is not.
+	config["scopes"] = []interface{}{"openid", "system/*.read"}
+	config["auto_grant_scopes"] = []interface{}{"openid"}
+	config["auto_approve_scopes"] = []interface{}{"openid"}
+	diff, err := r.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(config), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, diags := r.Apply(context.Background(), nil, diff, c)
+	if len(diags) != 1 || diags[0].Severity != diag.Warning || !strings.Contains(diags[0].Detail, "The public client my-app is granted the wildcard system scopes system/*.read") {
+		t.Errorf("a public client with wildcard system scopes is created with %+v", diags)
+	}
+
+	config["jwks_url"] = "https://my-app.example.org/jwks"
+	diff, err = r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, diags := r.Apply(context.Background(), state, diff, c); len(diags) > 0 {
+		t.Errorf("a client authenticating with a key is updated with %+v", diags)
+	}
+}
+
+func TestOpenIdClientRules(t *testing.T) {
+	r := resourceOpenIdClient()
+	client := func(attributes map[string]interface{}) map[string]interface{} {
+		config := map[string]interface{}{
+			"node_id":             "Master",
+			"client_id":           "my-app",
+			"client_name":         "My App",
+			"allowed_grant_types": []interface{}{"CLIENT_CREDENTIALS"},
+		}
+		for k, v := range attributes {
+			config[k] = v
+		}
+		return config
+	}
+
+	rules := []struct {
+		config map[string]interface{}
+		err    string
+	}{
+		{client(map[string]interface{}{"secret_required": true}), "client_secrets: secret_required is true, but the client has no client_secrets"},
+		{client(map[string]interface{}{"allowed_grant_types": []interface{}{"AUTHORIZATION_CODE"}}), "registered_redirect_uris: allowed_grant_types includes AUTHORIZATION_CODE, but the client has no registered_redirect_uris"},
+		{client(map[string]interface{}{"allowed_grant_types": []interface{}{"JWT_BEARER"}}), "jwks_url: allowed_grant_types includes JWT_BEARER, but the client has neither jwks_url nor public_jwks"},
+		{client(map[string]interface{}{"allowed_grant_types": []interface{}{"REFRESH_TOKEN"}}), "refresh_token_validity_seconds: allowed_grant_types includes REFRESH_TOKEN, but refresh_token_validity_seconds is not set"},
+		{client(map[string]interface{}{"allowed_grant_types": []interface{}{"REFRESH_TOKEN"}, "refresh_token_validity_seconds": 0}), "refresh_token_validity_seconds: allowed_grant_types includes REFRESH_TOKEN, but refresh_token_validity_seconds is 0"},
+	}
+	for _, rule := range rules {
+		_, err := r.Diff(context.Background(), testRawConfigState(r, rule.config), terraform.NewResourceConfigRaw(rule.config), nil)
+		if err == nil || !strings.HasPrefix(err.Error(), rule.err) {
+			t.Errorf("expected the plan to fail with %q, got %v", rule.err, err)
+		}
+	}
+
+	// Every broken rule is reported at once.
+	broken := client(map[string]interface{}{
+		"secret_required":     true,
+		"allowed_grant_types": []interface{}{"AUTHORIZATION_CODE", "JWT_BEARER", "REFRESH_TOKEN"},
+	})
+	_, err := r.Diff(context.Background(), testRawConfigState(r, broken), terraform.NewResourceConfigRaw(broken), nil)
+	if err == nil || len(strings.Split(err.Error(), "\n")) != 4 {
+		t.Errorf("expected the plan to fail with the 4 rules, got %v", err)
+	}
+
+	valid := client(map[string]interface{}{
+		"secret_required":                true,
+		"client_secrets":                 []interface{}{map[string]interface{}{"secret": "my-app-secret"}},
+		"allowed_grant_types":            []interface{}{"AUTHORIZATION_CODE", "JWT_BEARER", "REFRESH_TOKEN"},
+		"registered_redirect_uris":       []interface{}{"https://my-app.example.org/callback", "http://localhost:8080/callback", "http://127.0.0.1/callback"},
+		"public_jwks":                    `{"keys": []}`,
+		"refresh_token_validity_seconds": 3600,
+	})
+	if diags := r.Validate(terraform.NewResourceConfigRaw(valid)); diags.HasError() {
+		t.Errorf("a valid client does not validate: %+v", diags)
+	}
+	if _, err := r.Diff(context.Background(), testRawConfigState(r, valid), terraform.NewResourceConfigRaw(valid), nil); err != nil {
+		t.Errorf("a valid client does not plan: %s", err)
+	}
+
+	for uri, expected := range map[string]string{
+		"http://my-app.example.org/callback":    "only allowed for localhost and loopback addresses",
+		"https://my-app.example.org/callback#x": "has a fragment",
+		"/callback":                             "not an absolute http or https URL",
+		"com.example.app:/callback":             "not an absolute http or https URL",
+	} {
+		valid["registered_redirect_uris"] = []interface{}{uri}
+		diags := r.Validate(terraform.NewResourceConfigRaw(valid))
+		if !diags.HasError() || !strings.Contains(diags[0].Detail, expected) {
+			t.Errorf("the redirect URI %s is not rejected with %q: %+v", uri, expected, diags)
+		}
+	}
+}
@@ -12,11 +12,26 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/zedwerks/terraform-smilecdr/provider/helper/validations"
 	"github.com/zedwerks/terraform-smilecdr/smilecdr"
 )
 
+// The script sources of the identity provider, see scriptSource.
+var openIdIdentityProviderScriptSources = []scriptSource{
+	{
+		attribute:     "federation_auth_script_source",
+		textAttribute: "federation_auth_script_text",
+		validate:      validations.IsScript("federation authentication callback", "authenticate-success"),
+	},
+	{
+		attribute:     "federation_user_mapping_script_source",
+		textAttribute: "federation_user_mapping_script_text",
+		validate:      validations.IsScript("federation user mapping", "user-mapping"),
+	},
+}
+
 func resourceOpenIdIdentityProvider() *schema.Resource {
 	return &schema.Resource{
 		CreateContext: resourceOpenIdIdentityProviderCreate,
@@ -26,7 +41,11 @@ func resourceOpenIdIdentityProvider() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: resourceOpenIdIdentityProviderImport,
 		},
-		Schema: map[string]*schema.Schema{
+		CustomizeDiff: customdiff.All(
+			defaultNodeIdDiff,
+			scriptSourcesDiff(openIdIdentityProviderScriptSources),
+		),
+		Schema: scriptSourcesSchema(openIdIdentityProviderScriptSources, map[string]*schema.Schema{
 			"pid": {
 				Type:     schema.TypeInt,
 				Computed: true,
@@ -51,11 +70,7 @@ func resourceOpenIdIdentityProvider() *schema.Resource {
 				Optional:         true,
 				ValidateDiagFunc: validations.ValidateDiagFunc(validation.StringLenBetween(9, 512)),
 			},
-			"node_id": {
-				Type:     schema.TypeString,
-				Optional: true,
-				Default:  "Master",
-			},
+			"node_id": nodeIdSchema(),
 			"module_id": {
 				Type:     schema.TypeString,
 				Optional: true,
@@ -99,18 +114,20 @@ func resourceOpenIdIdentityProvider() *schema.Resource {
 				ValidateDiagFunc: validations.ValidateDiagFunc(validation.IsURLWithHTTPorHTTPS),
 			},
 			"federation_auth_script_text": {
-				Type:     schema.TypeString,
-				Optional: true,
+				Type:             schema.TypeString,
+				Optional:         true,
+				ValidateDiagFunc: openIdIdentityProviderScriptSources[0].validate,
 			},
 			"federation_user_mapping_script_text": {
-				Type:     schema.TypeString,
-				Optional: true,
+				Type:             schema.TypeString,
+				Optional:         true,
+				ValidateDiagFunc: openIdIdentityProviderScriptSources[1].validate,
 			},
 			"archived_at": {
 				Type:     schema.TypeString,
 				Optional: true,
 			},
-		},
+		}),
 	}
 }
 
@@ -137,6 +154,22 @@ func resource2OpenIdIdentityProvider(d *schema.ResourceData) (*smilecdr.OpenIdId
 		ArchivedAt:                      d.Get("archived_at").(string),
 	}
 
+	for _, source := range openIdIdentityProviderScriptSources {
+		script, ok, err := expandScriptSource(d, source)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		switch source.textAttribute {
+		case "federation_auth_script_text":
+			openidIdp.FederationAuthScriptText = script
+		case "federation_user_mapping_script_text":
+			openidIdp.FederationUserMappingScriptText = script
+		}
+	}
+
 	return openidIdp, nil
 }
 
@@ -178,6 +211,10 @@ func resourceOpenIdIdentityProviderRead(ctx context.Context, d *schema.ResourceD
 	issuerUrl := d.Get("issuer").(string)
 
 	provider, err := c.GetOpenIdIdentityProvider(ctx, nodeId, moduleId, issuerUrl)
+	if smilecdr.IsNotFound(err) {
+		d.SetId("") // removed outside of Terraform
+		return nil
+	}
 
 	if err != nil {
 		return diag.FromErr(err)
@@ -199,8 +236,8 @@ func resourceOpenIdIdentityProviderRead(ctx context.Context, d *schema.ResourceD
 	d.Set("federation_token_url", provider.FederationTokenUrl)
 	d.Set("federation_user_info_url", provider.FederationUserInfoUrl)
 	d.Set("federation_jwk_set_url", provider.FederationJwkSetUrl)
-	d.Set("federation_auth_script_text", provider.FederationAuthScriptText)
-	d.Set("federation_user_mapping_script_text", provider.FederationUserMappingScriptText)
+	flattenScriptSource(d, openIdIdentityProviderScriptSources[0], provider.FederationAuthScriptText)
+	flattenScriptSource(d, openIdIdentityProviderScriptSources[1], provider.FederationUserMappingScriptText)
 	d.Set("archived_at", provider.ArchivedAt)
 
 	return diags
@@ -262,14 +299,14 @@ func resourceOpenIdIdentityProviderImport(ctx context.Context, d *schema.Resourc
 		return nil, fmt.Errorf("invalid import. supported import formats: {{nodeId}}/{{moduleId}}?issuer_url={{issuerUrl}}")
 	}
 
-	_, err := c.GetOpenIdIdentityProvider(ctx, moduleParts[0], moduleParts[1], parts[2])
+	_, err := c.GetOpenIdIdentityProvider(ctx, moduleParts[0], moduleParts[1], parts[1])
 	if err != nil {
 		return nil, err
 	}
 
 	d.Set("node_id", moduleParts[0])
 	d.Set("module_id", moduleParts[1])
-	d.Set("issuer", parts[2])
+	d.Set("issuer", parts[1])
 
 	diagnostics := resourceOpenIdIdentityProviderRead(ctx, d, meta)
 	if diagnostics.HasError() {
@@ -0,0 +1,97 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zedwerks/terraform-smilecdr/provider/helper/validations"
+)
+
+// A permission set only lives in the Terraform state. Smile CDR has no notion of it: the provider expands
+// the sets attached to a user or client into plain permissions when writing that user or client.
+func resourcePermissionSet() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourcePermissionSetCreate,
+		ReadContext:   resourcePermissionSetRead,
+		UpdateContext: resourcePermissionSetUpdate,
+		DeleteContext: resourcePermissionSetDelete,
+		CustomizeDiff: customdiff.All(
+			validateAuthoritiesDiff("authorities"),
+			customdiff.ComputedIf("definition", func(ctx context.Context, d *schema.ResourceDiff, m interface{}) bool {
+				return d.HasChanges("name", "authorities")
+			}),
+		),
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				Description:      "The name of the permission set, reported in 'effective_authorities' of the users and 'effective_permissions' of the clients it is attached to.",
+				ValidateDiagFunc: validations.ValidateDiagFunc(validation.StringDoesNotContainAny(" \t\n\r")),
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"authorities": {
+				Type:     schema.TypeSet,
+				Required: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"permission": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"argument": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+					},
+				},
+			},
+			"definition": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The encoded permission set, to be listed in 'permission_sets' of a smilecdr_user or smilecdr_openid_client.",
+			},
+		},
+	}
+}
+
+func resourcePermissionSetCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	d.SetId(d.Get("name").(string))
+
+	return resourcePermissionSetRead(ctx, d, m)
+}
+
+func resourcePermissionSetRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	name := d.Get("name").(string)
+	authorities := authoritiesFromSet(d.Get("authorities").(*schema.Set))
+
+	definition, err := encodePermissionSet(name, authorities)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.Set("definition", definition)
+
+	return nil
+}
+
+func resourcePermissionSetUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	return resourcePermissionSetRead(ctx, d, m)
+}
+
+func resourcePermissionSetDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	d.SetId("") // Nothing to remove on the server
+
+	return nil
+}
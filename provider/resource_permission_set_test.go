@@ -0,0 +1,175 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr/smilecdrtest"
+)
+
+func TestSmileCdrPermissionSet(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testPermissionSetConfig_user(),
+				Check: resource.ComposeTestCheckFunc(
+					testUserExists("smilecdr_user.permission_set_user"),
+					resource.TestCheckResourceAttr("smilecdr_user.permission_set_user", "effective_authorities.#", "3"),
+					resource.TestCheckResourceAttr("smilecdr_user.permission_set_user", "authorities.#", "1"),
+				),
+			},
+		},
+	})
+}
+
+func testPermissionSetConfig_user() string {
+
+	username := "U_" + strings.ToUpper(acctest.RandString(8))
+
+	return fmt.Sprintf(`resource "smilecdr_permission_set" "fhir_reader" {
+		name = "fhir_reader"
+		description = "Read access to the FHIR endpoint"
+
+		authorities {
+			permission = "FHIR_ALL_READ"
+		}
+		authorities {
+			permission = "ROLE_FHIR_CLIENT"
+		}
+	}
+
+	resource "smilecdr_user" "permission_set_user" {
+		node_id = "Master"
+		module_id = "local_security"
+  		username = "%s"
+		password = "Passw0rd"
+		family_name = "PermissionSet"
+  		given_name = "User"
+
+		authorities {
+			permission = "FHIR_CAPABILITIES"
+		}
+		permission_sets = [smilecdr_permission_set.fhir_reader.definition]
+
+		lifecycle {
+			ignore_changes = [
+				password,
+			]
+		}
+	}`, username)
+}
+
+func TestPermissionSetExpansion(t *testing.T) {
+	server := smilecdrtest.NewServer()
+	c := smilecdr.NewClient(context.Background(), server.Start(), "admin", "password")
+	defer server.Close()
+
+	readers, err := encodePermissionSet("readers", []authority{
+		{Permission: "FHIR_ALL_READ"},
+		{Permission: "FHIR_READ_ALL_IN_COMPARTMENT", Argument: "Patient/123"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	direct := []interface{}{map[string]interface{}{"permission": "ACCESS_ADMIN_JSON"}}
+
+	// effective lists "permission(argument)=set,set" for every permission in the state.
+	effective := func(state *terraform.InstanceState, attribute string) []string {
+		var result []string
+		count, _ := strconv.Atoi(state.Attributes[attribute+".#"])
+		for i := 0; i < count; i++ {
+			prefix := fmt.Sprintf("%s.%d.", attribute, i)
+			a := authority{Permission: state.Attributes[prefix+"permission"], Argument: state.Attributes[prefix+"argument"]}
+			var grantedBy []string
+			sets, _ := strconv.Atoi(state.Attributes[prefix+"granted_by.#"])
+			for j := 0; j < sets; j++ {
+				grantedBy = append(grantedBy, state.Attributes[fmt.Sprintf("%sgranted_by.%d", prefix, j)])
+			}
+			result = append(result, a.String()+"="+strings.Join(grantedBy, ","))
+		}
+		sort.Strings(result)
+		return result
+	}
+	expected := []string{
+		"ACCESS_ADMIN_JSON=",
+		"FHIR_ALL_READ=readers",
+		"FHIR_READ_ALL_IN_COMPARTMENT(Patient/123)=readers",
+	}
+	unmanagedWarning := func(diags diag.Diagnostics) bool {
+		for _, d := range diags {
+			if d.Severity == diag.Warning && d.Summary == "Permissions found that are not granted by any attached permission set" && strings.Contains(d.Detail, "ROLE_FHIR_CLIENT_SUPERUSER") {
+				return true
+			}
+		}
+		return false
+	}
+
+	t.Run("user", func(t *testing.T) {
+		r := resourceUser()
+		state := testApply(t, r, nil, map[string]interface{}{
+			"node_id":         "Master",
+			"username":        "reader",
+			"password":        "Passw0rd!",
+			"authorities":     direct,
+			"permission_sets": []interface{}{readers},
+		}, c)
+
+		if len(server.Users[0].Authorities) != 3 {
+			t.Errorf("the server holds the permissions %v", server.Users[0].Authorities)
+		}
+		if got := effective(state, "effective_authorities"); !reflect.DeepEqual(got, expected) {
+			t.Errorf("effective_authorities is %v, expected %v", got, expected)
+		}
+		if state.Attributes["authorities.#"] != "1" {
+			t.Errorf("the permissions of the set are reported in authorities: %v", state.Attributes["authorities.#"])
+		}
+
+		server.Users[0].Authorities = append(server.Users[0].Authorities, smilecdr.UserAuthorities{Permission: "ROLE_FHIR_CLIENT_SUPERUSER"})
+		_, diags := r.RefreshWithoutUpgrade(context.Background(), state, c)
+		if diags.HasError() || !unmanagedWarning(diags) {
+			t.Errorf("a permission added on the server does not warn: %+v", diags)
+		}
+	})
+
+	t.Run("client", func(t *testing.T) {
+		r := resourceOpenIdClient()
+		state := testApply(t, r, nil, map[string]interface{}{
+			"node_id":                  "Master",
+			"client_id":                "reader",
+			"client_name":              "Reader",
+			"allowed_grant_types":      []interface{}{"AUTHORIZATION_CODE"},
+			"registered_redirect_uris": []interface{}{"https://reader.example.org/callback"},
+			"client_secrets":           []interface{}{map[string]interface{}{"secret": "reader-secret"}},
+			"permissions":              direct,
+			"permission_sets":          []interface{}{readers},
+		}, c)
+
+		if len(server.OpenIdClients[0].Permissions) != 3 {
+			t.Errorf("the server holds the permissions %v", server.OpenIdClients[0].Permissions)
+		}
+		if got := effective(state, "effective_permissions"); !reflect.DeepEqual(got, expected) {
+			t.Errorf("effective_permissions is %v, expected %v", got, expected)
+		}
+
+		server.OpenIdClients[0].Permissions = append(server.OpenIdClients[0].Permissions, smilecdr.UserPermission{Permission: "ROLE_FHIR_CLIENT_SUPERUSER"})
+		_, diags := r.RefreshWithoutUpgrade(context.Background(), state, c)
+		if diags.HasError() || !unmanagedWarning(diags) {
+			t.Errorf("a permission added on the server does not warn: %+v", diags)
+		}
+	})
+}
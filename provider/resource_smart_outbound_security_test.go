@@ -4,12 +4,19 @@
 package provider
 
 import (
+	"context"
 	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/zedwerks/terraform-smilecdr/provider/helper/validations"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr/smilecdrtest"
 )
 
 func TestSmartOutboundSecurity(t *testing.T) {
@@ -55,7 +62,7 @@ func testSmartOutboundConfig() string {
 		oidc_issuer_url                       = "http://keycloak:8080/auth/realms/lra"
 		oidc_federate_mode_enabled            = true
 		smart_authorization_enforce_approved_scopes = true
-		smart_authorization_scopes_supported        = "launch fhirUser openid profile patient/*.read"
+		smart_authorization_scopes_supported        = ["launch", "fhirUser", "openid", "profile", "patient/*.read"]
 		sessions_max_concurrent_sessions_per_user   = 3
 		dependency_fhir_persistence_module          = "PERSISTENCE_ALL"
 }`, moduleName)
@@ -73,7 +80,7 @@ func testSmartOutboundConfigWithJavaScript() string {
 		oidc_issuer_url                       = "http://smilecdr:9000/auth"
 		oidc_federate_mode_enabled            = true
 		smart_authorization_enforce_approved_scopes = true
-		smart_authorization_scopes_supported        = "launch fhirUser openid profile patient/*.read"
+		smart_authorization_scopes_supported        = ["launch", "fhirUser", "openid", "profile", "patient/*.read"]
 		sessions_max_concurrent_sessions_per_user   = 3
 		dependency_fhir_persistence_module          = "PERSISTENCE_ALL"
 		smart_callback_post_authorize_script_text =  "Log.info('Authorization Callback Script goes here');"
@@ -97,3 +104,243 @@ func testAccSmartOutboundModuleExists(n string) resource.TestCheckFunc {
 		return nil
 	}
 }
+
+func TestSmartOutboundSecurityExplicitFalse(t *testing.T) {
+	moduleName := "smart_" + acctest.RandString(8)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testSmartOutboundOptionsConfig(moduleName, "cors_enabled = true\nhttp_listener_unhealthy_response_code = 503"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccSmartOutboundOption("smilecdr_smart_outbound_security.options", "cors.enable", "true"),
+					testAccSmartOutboundOption("smilecdr_smart_outbound_security.options", "endpoint_health.status_code_if_unhealthy", "503"),
+				),
+			},
+			{
+				Config: testSmartOutboundOptionsConfig(moduleName, "cors_enabled = false\nhttp_listener_unhealthy_response_code = 0"),
+				Check: resource.ComposeTestCheckFunc(
+					testAccSmartOutboundOption("smilecdr_smart_outbound_security.options", "cors.enable", "false"),
+					testAccSmartOutboundOption("smilecdr_smart_outbound_security.options", "endpoint_health.status_code_if_unhealthy", "0"),
+				),
+			},
+			{
+				Config: testSmartOutboundOptionsConfig(moduleName, "cors_enabled = true"),
+			},
+			{
+				// Removed from the configuration, the option is reset.
+				Config: testSmartOutboundOptionsConfig(moduleName, ""),
+				Check: resource.ComposeTestCheckFunc(
+					testAccSmartOutboundOption("smilecdr_smart_outbound_security.options", "cors.enable", ""),
+				),
+			},
+		},
+	})
+}
+
+func TestSmartOutboundSecurityResetOptions(t *testing.T) {
+	server := smilecdrtest.NewServer()
+	c := smilecdr.NewClient(context.Background(), server.Start(), "admin", "password")
+	defer server.Close()
+
+	r := resourceSmartOutboundSecurity()
+	configure := func(options map[string]interface{}) map[string]interface{} {
+		config := map[string]interface{}{
+			"node_id":            "Master",
+			"module_id":          "smart_auth",
+			"http_listener_port": 9200,
+			"oidc_issuer_url":    "https://auth.example.org",
+		}
+		for attribute, value := range options {
+			config[attribute] = value
+		}
+		return config
+	}
+	expectOptions := func(when string, expected map[string]string) {
+		t.Helper()
+		module := server.Modules["Master/smart_auth"]
+		for key, value := range expected {
+			if actual, _ := module.LookupOptionOk(key); actual != value {
+				t.Errorf("%s, %s is %q on the server, expected %q", when, key, actual, value)
+			}
+		}
+	}
+
+	config := configure(map[string]interface{}{
+		"cors_enabled":                          false,
+		"http_listener_unhealthy_response_code": 0,
+		"anonymous_access_enabled":              true,
+	})
+	state := testApply(t, r, testRawConfigState(r, config), config, c)
+	expectOptions("set explicitly", map[string]string{
+		"cors.enable": "false",
+		"endpoint_health.status_code_if_unhealthy": "0",
+		"anonymous.access.enabled":                 "true",
+	})
+
+	// Removed from the configuration, options are reset to their default, or blank without one.
+	config = configure(nil)
+	testApply(t, r, testRawUpdateState(r, state, config), config, c)
+	expectOptions("removed", map[string]string{
+		"cors.enable": "",
+		"endpoint_health.status_code_if_unhealthy": "",
+		"anonymous.access.enabled":                 "false",
+		"anonymous.access.account_username":        "ANONYMOUS",
+	})
+}
+
+func testSmartOutboundOptionsConfig(moduleName string, options string) string {
+
+	return fmt.Sprintf(`resource "smilecdr_smart_outbound_security" "options" {
+		module_id                          = "%s"
+		http_listener_port                 = 9998
+		oidc_issuer_url                    = "http://localhost:9998"
+		dependency_fhir_persistence_module = "PERSISTENCE_ALL"
+		%s
+}`, moduleName, options)
+}
+
+// testAccSmartOutboundOption checks the value of a module option on the server. A blank value also matches
+// a missing option.
+func testAccSmartOutboundOption(n string, key string, expected string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		c := testAccProviders["smilecdr"].Meta().(*smilecdr.Client)
+		moduleConfig, err := c.GetModuleConfig(context.Background(), rs.Primary.Attributes["node_id"], rs.Primary.Attributes["module_id"])
+		if err != nil {
+			return err
+		}
+		if value, _ := moduleConfig.LookupOptionOk(key); value != expected {
+			return fmt.Errorf("option %s is %q, expected %q", key, value, expected)
+		}
+		return nil
+	}
+}
+
+func TestSmartScopesAndCapabilities(t *testing.T) {
+	validScopes := []string{
+		"openid", "fhirUser", "launch/patient", "offline_access",
+		"patient/*.read", "user/Observation.write", "system/*.*",
+		"patient/Observation.rs", "user/*.cruds", "patient/Observation.rs?category=laboratory",
+		"patient/Condition.s?category=problem-list-item&clinical-status=active",
+	}
+	for _, scope := range validScopes {
+		if diags := validations.IsSmartScope(scope, nil); diags.HasError() {
+			t.Errorf("%s is rejected: %s", scope, diags[0].Detail)
+		}
+	}
+
+	invalidScopes := map[string]string{
+		"patient/Observation.sr":                       "in this order",
+		"patient/Observation.":                         "neither a known",
+		"patient/Observations.read":                    "not a FHIR R4 resource type",
+		"practitioner/*.read":                          "neither a known",
+		"patient/Observation.read?category=laboratory": "cannot have a query",
+		"patient/Observation.rs?category":              "not a query of search parameters",
+		"launch/location":                              "neither a known",
+	}
+	for scope, expected := range invalidScopes {
+		if diags := validations.IsSmartScope(scope, nil); !diags.HasError() || !strings.Contains(diags[0].Detail, expected) {
+			t.Errorf("%s is not rejected with %q: %v", scope, expected, diags)
+		}
+	}
+
+	if diags := validations.IsSmartCapability("permission-v2", nil); diags.HasError() {
+		t.Errorf("permission-v2 is rejected: %v", diags)
+	}
+	if diags := validations.IsSmartCapability("launch-patient", nil); !diags.HasError() {
+		t.Error("launch-patient is not rejected")
+	}
+}
+
+// TestSmartOutboundSecuritySets checks that the scopes, capabilities and audiences are sent sorted, and
+// that reordering them, in the configuration or on the server, plans no change.
+func TestSmartOutboundSecuritySets(t *testing.T) {
+	server := smilecdrtest.NewServer()
+	c := smilecdr.NewClient(context.Background(), server.Start(), "admin", "password")
+	defer server.Close()
+
+	r := resourceSmartOutboundSecurity()
+	config := map[string]interface{}{
+		"node_id":                                   "Master",
+		"module_id":                                 "smart_auth",
+		"http_listener_port":                        9200,
+		"oidc_issuer_url":                           "https://auth.example.org",
+		"smart_authorization_scopes_supported":      []interface{}{"openid", "launch", "patient/*.read"},
+		"oidc_smart_capabilities_list":              []interface{}{"launch-standalone", "client-public"},
+		"smart_authorization_allowed_audience_list": []interface{}{"https://fhir.example.org/r4", "https://fhir.example.org/r5"},
+	}
+	state := testApply(t, r, nil, config, c)
+	module := server.Modules["Master/smart_auth"]
+	for key, expected := range map[string]string{
+		"smart_configuration.scopes_supported": "launch openid patient/*.read",
+		"smart_capabilities_list":              "client-public\nlaunch-standalone",
+		"allowed_audience_list":                "https://fhir.example.org/r4,https://fhir.example.org/r5",
+	} {
+		if value, _ := module.LookupOptionOk(key); value != expected {
+			t.Errorf("%s is %q on the server, expected %q", key, value, expected)
+		}
+	}
+
+	config["smart_authorization_scopes_supported"] = []interface{}{"patient/*.read", "openid", "launch"}
+	module.SetOption("smart_configuration.scopes_supported", "patient/*.read  launch openid")
+	module.SetOption("allowed_audience_list", "https://fhir.example.org/r5, https://fhir.example.org/r4")
+	server.Modules["Master/smart_auth"] = module
+	state, diags := r.RefreshWithoutUpgrade(context.Background(), state, c)
+	if diags.HasError() {
+		t.Fatalf("refresh failed: %+v", diags)
+	}
+	diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != nil && !diff.Empty() {
+		t.Errorf("reordering plans %v", diff.Attributes)
+	}
+}
+
+func TestSmartSecurityStateUpgrade(t *testing.T) {
+	ctx := context.Background()
+	outbound := resourceSmartOutboundSecurity().StateUpgraders[0]
+	state, err := outbound.Upgrade(ctx, map[string]interface{}{
+		"module_id":                                 "smart_auth",
+		"smart_authorization_scopes_supported":      "openid  patient/*.read launch",
+		"oidc_smart_capabilities_list":              []interface{}{"launch-ehr", "client-public"},
+		"smart_authorization_allowed_audience_list": "https://fhir.example.org/r4 , https://fhir.example.org/r5",
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for attribute, expected := range map[string][]string{
+		"smart_authorization_scopes_supported":      {"launch", "openid", "patient/*.read"},
+		"oidc_smart_capabilities_list":              {"client-public", "launch-ehr"},
+		"smart_authorization_allowed_audience_list": {"https://fhir.example.org/r4", "https://fhir.example.org/r5"},
+	} {
+		var items []string
+		for _, item := range state[attribute].([]interface{}) {
+			items = append(items, item.(string))
+		}
+		sort.Strings(items)
+		if !reflect.DeepEqual(items, expected) {
+			t.Errorf("%s is upgraded to %v, expected %v", attribute, items, expected)
+		}
+	}
+	if state["module_id"] != "smart_auth" {
+		t.Errorf("the upgrade changed the module ID to %v", state["module_id"])
+	}
+
+	inbound := resourceSmartInboundSecurity().StateUpgraders[0]
+	state, err = inbound.Upgrade(ctx, map[string]interface{}{"smart_configuration_scopes_supported": "openid profile email"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scopes := state["smart_configuration_scopes_supported"].([]interface{}); len(scopes) != 3 {
+		t.Errorf("the inbound scopes are upgraded to %v", scopes)
+	}
+}
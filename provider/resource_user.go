@@ -10,7 +10,9 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/zedwerks/terraform-smilecdr/provider/helper/validations"
@@ -27,6 +29,19 @@ func resourceUser() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: resourceUserImport,
 		},
+		CustomizeDiff: customdiff.All(
+			defaultNodeIdDiff,
+			validateAuthoritiesDiff("authorities"),
+			validatePasswordDiff,
+			customdiff.ComputedIf("effective_authorities", func(ctx context.Context, d *schema.ResourceDiff, m interface{}) bool {
+				return d.HasChanges("authorities", "permission_sets")
+			}),
+			customdiff.ComputedIf("failed_login_count", userLockChanged),
+			customdiff.ComputedIf("locked_at", userLockChanged),
+			customdiff.ComputedIf("2fa_status", userTwoFactorAuthChanged),
+			customdiff.ComputedIf("2fa_enrolled", userTwoFactorAuthChanged),
+			customdiff.ComputedIf("2fa_enrolled_at", userTwoFactorAuthChanged),
+		),
 		Schema: map[string]*schema.Schema{
 			"created": {
 				Type:     schema.TypeBool,
@@ -36,11 +51,7 @@ func resourceUser() *schema.Resource {
 				Type:     schema.TypeInt,
 				Computed: true,
 			},
-			"node_id": {
-				Type:     schema.TypeString,
-				Optional: true,
-				Default:  "Master",
-			},
+			"node_id": nodeIdSchema(),
 			"module_id": {
 				Type:     schema.TypeString,
 				Optional: true,
@@ -55,6 +66,7 @@ func resourceUser() *schema.Resource {
 				Type:             schema.TypeString,
 				Required:         true,
 				Sensitive:        true,
+				Description:      "Checked at plan time against the password strength options of the module, when it is a local inbound security module.",
 				ValidateDiagFunc: validations.ValidateDiagFunc(validation.StringLenBetween(8, 512)),
 				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
 					created := d.Get("created").(bool) || false
@@ -82,6 +94,21 @@ func resourceUser() *schema.Resource {
 				Type:     schema.TypeBool,
 				Optional: true,
 			},
+			"lockout_policy": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Default:          "ignore_server_locks",
+				Description:      "How to treat an account locked on the server, e.g. after failed logins, while 'account_locked' is false. 'ignore_server_locks' leaves the lock in place without reporting a change, 'enforce_unlocked' unlocks the account on the next apply.",
+				ValidateDiagFunc: validations.ValidateDiagFunc(validation.StringInSlice([]string{"ignore_server_locks", "enforce_unlocked"}, false)),
+			},
+			"failed_login_count": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"locked_at": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
 			"system_user": {
 				Type:     schema.TypeBool,
 				Optional: true,
@@ -102,9 +129,29 @@ func resourceUser() *schema.Resource {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
+			"require_2fa": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Require the user to enrol a TOTP key for two-factor authentication.",
+			},
+			"reset_2fa_trigger": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Any value. Changing it discards the enrolled TOTP key of the user, who must enrol again on the next login.",
+			},
+			"2fa_enrolled": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"2fa_enrolled_at": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
 			"authorities": {
-				Type:     schema.TypeSet,
-				Optional: true,
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "The permissions of the user. A user whose permissions are also granted by `smilecdr_user_authorities` must ignore changes to them, with `lifecycle { ignore_changes = [authorities] }`, or each apply reverts the permissions written by the other resource.",
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"permission": {
@@ -118,41 +165,47 @@ func resourceUser() *schema.Resource {
 					},
 				},
 			},
+			"permission_sets":       permissionSetsSchema(),
+			"effective_authorities": effectiveAuthoritiesSchema(),
 		},
 	}
 }
 
-func flattenUserAuthorities(permissions []smilecdr.UserAuthorities) []interface{} {
-	perms := make([]interface{}, len(permissions))
+func userAuthoritiesToAuthorities(userAuthorities []smilecdr.UserAuthorities) []authority {
+	authorities := make([]authority, len(userAuthorities))
 
-	for i, p := range permissions {
-		perms[i] = map[string]interface{}{
-			"permission": p.Permission,
-			"argument":   p.Argument,
-		}
+	for i, a := range userAuthorities {
+		authorities[i] = authority{Permission: a.Permission, Argument: a.Argument}
 	}
 
-	return perms
+	return authorities
+}
+
+func userLockChanged(ctx context.Context, d *schema.ResourceDiff, m interface{}) bool {
+	return d.HasChange("account_locked")
+}
+
+func userTwoFactorAuthChanged(ctx context.Context, d *schema.ResourceDiff, m interface{}) bool {
+	return d.HasChanges("require_2fa", "reset_2fa_trigger")
 }
 
 func resourceDataToUser(d *schema.ResourceData) (*smilecdr.User, error) {
 
 	fmt.Println("In resourceDataToUser...")
 
-	//authorities := d.Get("authorities").([]interface{})
+	sets, err := decodePermissionSets(d)
+	if err != nil {
+		return nil, err
+	}
 
-	authorities := d.Get("authorities").(*schema.Set).List()
+	authorities := expandPermissionSets(authoritiesFromSet(d.Get("authorities").(*schema.Set)), sets)
 
 	userAuthorities := []smilecdr.UserAuthorities{}
-	for _, authority := range authorities {
-		s := authority.(map[string]interface{})
-		if s["permission"] != nil || s["permission"].(string) != "" {
-			perm := smilecdr.UserAuthorities{
-				Permission: s["permission"].(string),
-				Argument:   s["argument"].(string),
-			}
-			userAuthorities = append(userAuthorities, perm)
-		}
+	for _, a := range authorities {
+		userAuthorities = append(userAuthorities, smilecdr.UserAuthorities{
+			Permission: a.Permission,
+			Argument:   a.Argument,
+		})
 	}
 
 	smileUser := &smilecdr.User{
@@ -163,14 +216,19 @@ func resourceDataToUser(d *schema.ResourceData) (*smilecdr.User, error) {
 		Password:            d.Get("password").(string),
 		FamilyName:          d.Get("family_name").(string),
 		GivenName:           d.Get("given_name").(string),
-		AccountLocked:       d.Get("account_locked").(bool),
-		SystemUser:          d.Get("system_user").(bool),
-		AccountDisabled:     d.Get("account_disabled").(bool),
-		External:            d.Get("external").(bool),
-		ServiceAccount:      d.Get("service_account").(bool),
+		AccountLocked:       smilecdr.Bool(d.Get("account_locked").(bool)),
+		SystemUser:          smilecdr.Bool(d.Get("system_user").(bool)),
+		AccountDisabled:     smilecdr.Bool(d.Get("account_disabled").(bool)),
+		External:            smilecdr.Bool(d.Get("external").(bool)),
+		ServiceAccount:      smilecdr.Bool(d.Get("service_account").(bool)),
 		TwoFactorAuthStatus: d.Get("2fa_status").(string),
 		Authorities:         userAuthorities,
 	}
+	// A lock placed by the server is hidden from the state under ignore_server_locks, so the lock is
+	// only sent when 'account_locked' changes, or any update would unlock the account.
+	if d.Id() != "" && d.Get("lockout_policy").(string) == "ignore_server_locks" && !d.HasChange("account_locked") {
+		smileUser.AccountLocked = nil
+	}
 
 	return smileUser, nil
 
@@ -199,6 +257,13 @@ func resourceUserCreate(ctx context.Context, d *schema.ResourceData, m interface
 	d.Set("pid", o.Pid)
 	d.SetId(strconv.Itoa(o.Pid)) // the primary resource identifier. must be unique.
 
+	if d.Get("require_2fa").(bool) {
+		err = c.SetUserTwoFactorAuthRequired(ctx, o.NodeId, o.ModuleId, o.Pid, true)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
 	return resourceUserRead(ctx, d, m)
 }
 
@@ -215,6 +280,10 @@ func resourceUserRead(ctx context.Context, d *schema.ResourceData, m interface{}
 	moduleId := d.Get("module_id").(string)
 
 	user, err := c.GetUser(ctx, nodeId, moduleId, pid)
+	if smilecdr.IsNotFound(err) {
+		d.SetId("") // removed outside of Terraform
+		return nil
+	}
 
 	if err != nil {
 		diags := diag.FromErr(err)
@@ -225,6 +294,16 @@ func resourceUserRead(ctx context.Context, d *schema.ResourceData, m interface{}
 		return diags
 	}
 
+	sets, err := decodePermissionSets(d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	configured := authoritiesFromSet(d.Get("authorities").(*schema.Set))
+	direct, effective, unmanaged := attributePermissionSets(userAuthoritiesToAuthorities(user.Authorities), configured, sets)
+	if len(sets) > 0 && len(unmanaged) > 0 {
+		diags = append(diags, unmanagedAuthoritiesWarning(unmanaged)...)
+	}
+
 	d.SetId(strconv.Itoa(user.Pid))
 	d.Set("pid", user.Pid)
 	d.Set("node_id", user.NodeId)
@@ -233,14 +312,42 @@ func resourceUserRead(ctx context.Context, d *schema.ResourceData, m interface{}
 	d.Set("password", user.Password)
 	d.Set("family_name", user.FamilyName)
 	d.Set("given_name", user.GivenName)
-	d.Set("account_locked", user.AccountLocked)
-	d.Set("system_user", user.SystemUser)
-	d.Set("authorities", flattenUserAuthorities(user.Authorities))
-	d.Set("account_disabled", user.AccountDisabled)
-	d.Set("external", user.External)
-	d.Set("service_account", user.ServiceAccount)
+	// A lock placed by the server is only reported when the lockout policy enforces unlocked accounts.
+	locked := smilecdr.BoolValue(user.AccountLocked)
+	if locked && !d.Get("account_locked").(bool) && d.Get("lockout_policy").(string) == "ignore_server_locks" {
+		locked = false
+	}
+	d.Set("account_locked", locked)
+	d.Set("failed_login_count", user.FailedLoginCount)
+	d.Set("locked_at", user.LockedAt)
+	d.Set("system_user", smilecdr.BoolValue(user.SystemUser))
+	d.Set("authorities", flattenAuthorities(direct))
+	d.Set("effective_authorities", effective)
+	d.Set("account_disabled", smilecdr.BoolValue(user.AccountDisabled))
+	d.Set("external", smilecdr.BoolValue(user.External))
+	d.Set("service_account", smilecdr.BoolValue(user.ServiceAccount))
 	d.Set("2fa_status", user.TwoFactorAuthStatus)
 
+	tfa, err := c.GetUserTwoFactorAuth(ctx, nodeId, moduleId, pid)
+	if err == nil {
+		d.Set("require_2fa", tfa.Required)
+		d.Set("2fa_enrolled", tfa.Enrolled)
+		d.Set("2fa_enrolled_at", tfa.EnrolledAt)
+		if tfa.Status != "" {
+			d.Set("2fa_status", tfa.Status)
+		}
+	} else if d.Get("require_2fa").(bool) {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Unable to read the two-factor authentication enrolment of the user",
+			Detail:   err.Error(),
+		})
+	} else {
+		// Servers without two-factor authentication do not serve the endpoint, which only matters
+		// to users required to enrol.
+		tflog.Warn(ctx, "Unable to read the two-factor authentication enrolment of the user: "+err.Error())
+	}
+
 	return diags
 
 }
@@ -256,28 +363,72 @@ func resourceUserUpdate(ctx context.Context, d *schema.ResourceData, m interface
 		return diag.FromErr(mErr)
 	}
 
-	if d.HasChange("password") {
-		old, new := d.GetChange("password")
-		fmt.Printf("Sensitive data 'password' has changed from '%s' to '%s'\n", old, new)
+	// The stored password is only sent again when it was changed.
+	if !d.HasChange("password") {
+		user.Password = ""
 	}
 
 	d.SetId(strconv.Itoa(user.Pid))
 
-	_, err := c.PutUser(ctx, *user)
+	// Unlocking goes through its own call, so that it needs no full update of the user.
+	var err error
+	unlock := d.HasChange("account_locked") && !d.Get("account_locked").(bool)
+	if unlock {
+		err = c.UnlockUser(ctx, user.NodeId, user.ModuleId, user.Pid)
+		if err != nil {
+			diags := diag.FromErr(err)
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "Error unlocking user account",
+			})
+			return diags
+		}
+	}
 
-	if err != nil {
-		diags := diag.FromErr(err)
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Warning,
-			Summary:  "Error updating user record",
-		})
-		return diags
+	if userNeedsUpdate(d, unlock) {
+		_, err = c.PutUser(ctx, *user)
+
+		if err != nil {
+			diags := diag.FromErr(err)
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  "Error updating user record",
+			})
+			return diags
+		}
+	}
+
+	if d.HasChange("require_2fa") {
+		err = c.SetUserTwoFactorAuthRequired(ctx, user.NodeId, user.ModuleId, user.Pid, d.Get("require_2fa").(bool))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	// The trigger is one-shot: only a change to a new, non-empty value resets the key.
+	if d.HasChange("reset_2fa_trigger") && d.Get("reset_2fa_trigger").(string) != "" {
+		err = c.ResetUserTwoFactorAuthKey(ctx, user.NodeId, user.ModuleId, user.Pid)
+		if err != nil {
+			return diag.FromErr(err)
+		}
 	}
 
 	return resourceUserRead(ctx, d, m)
 
 }
 
+// userNeedsUpdate reports whether the change needs a full update of the user record, as opposed to
+// changes applied through their own calls or that only live in the Terraform state.
+func userNeedsUpdate(d *schema.ResourceData, unlocked bool) bool {
+	except := []string{
+		"lockout_policy", "require_2fa", "reset_2fa_trigger",
+		"2fa_status", "2fa_enrolled", "2fa_enrolled_at", "failed_login_count", "locked_at",
+	}
+	if unlocked {
+		except = append(except, "account_locked")
+	}
+	return d.HasChangesExcept(except...)
+}
+
 func resourceUserDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 
 	fmt.Println("Deleting User")
@@ -292,6 +443,7 @@ func resourceUserDelete(ctx context.Context, d *schema.ResourceData, m interface
 	if mErr != nil {
 		return diag.FromErr(mErr)
 	}
+	user.Password = "" // keep the stored password
 	d.SetId(strconv.Itoa(user.Pid))
 
 	_, err := c.PutUser(ctx, *user)
@@ -316,16 +468,19 @@ func resourceUserImport(ctx context.Context, d *schema.ResourceData, meta interf
 		return nil, fmt.Errorf("invalid import. supported import formats: {{nodeId}}/{{moduleId}}/{{pid}}")
 	}
 
-	pid, _ := strconv.Atoi(parts[2])
+	pid, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid import. pid '%s' is not a number", parts[2])
+	}
 
-	_, err := c.GetUser(ctx, parts[0], parts[1], pid)
+	_, err = c.GetUser(ctx, parts[0], parts[1], pid)
 	if err != nil {
 		return nil, err
 	}
 
 	d.Set("node_id", parts[0])
 	d.Set("module_id", parts[1])
-	d.Set("pid", parts[2])
+	d.Set("pid", pid)
 	d.SetId(parts[2])
 
 	diagnostics := resourceUserRead(ctx, d, meta)
@@ -0,0 +1,301 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/zedwerks/terraform-smilecdr/provider/helper/validations"
+
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+)
+
+// Several smilecdr_user_authorities resources may manage the same user. Each one reads the user,
+// changes its authorities and writes the whole user back, so the updates to a user are serialized.
+var userAuthoritiesLocks sync.Map
+
+func lockUserAuthorities(id string) func() {
+	lock, _ := userAuthoritiesLocks.LoadOrStore(id, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	return lock.(*sync.Mutex).Unlock
+}
+
+func resourceUserAuthorities() *schema.Resource {
+	return &schema.Resource{
+		Description:   "Grants permissions to an existing user, which it writes back whole. When the user is also managed by `smilecdr_user`, that resource must ignore changes to its permissions with `lifecycle { ignore_changes = [authorities] }`, or each apply reverts the permissions written by the other resource.",
+		CreateContext: resourceUserAuthoritiesCreate,
+		ReadContext:   resourceUserAuthoritiesRead,
+		UpdateContext: resourceUserAuthoritiesUpdate,
+		DeleteContext: resourceUserAuthoritiesDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceUserAuthoritiesImport,
+		},
+		CustomizeDiff: customdiff.All(
+			defaultNodeIdDiff,
+			validateAuthoritiesDiff("authorities"),
+		),
+		Schema: map[string]*schema.Schema{
+			"node_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+				Description: "The node ID of the user. Defaults to the `default_node_id` of the provider.",
+			},
+			"module_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Default:  "local_security",
+			},
+			"pid": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ExactlyOneOf: []string{"pid", "username"},
+				Description:  "The pid of the user. One of 'pid' or 'username' must be set.",
+			},
+			"username": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Computed:         true,
+				ForceNew:         true,
+				ExactlyOneOf:     []string{"pid", "username"},
+				ValidateDiagFunc: validations.ValidateDiagFunc(validation.StringIsNotWhiteSpace),
+				Description:      "The username of the user. One of 'pid' or 'username' must be set.",
+			},
+			"exclusive": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "When true, these authorities are the only ones the user holds, and any other authority is removed. When false, only the listed authorities are managed and the others are left untouched.",
+			},
+			"authorities": {
+				Type:     schema.TypeSet,
+				Required: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"permission": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"argument": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func authoritiesToUserAuthorities(authorities []authority) []smilecdr.UserAuthorities {
+	userAuthorities := []smilecdr.UserAuthorities{}
+
+	for _, a := range authorities {
+		userAuthorities = append(userAuthorities, smilecdr.UserAuthorities{
+			Permission: a.Permission,
+			Argument:   a.Argument,
+		})
+	}
+
+	return userAuthorities
+}
+
+// mergeUserAuthorities returns the authorities to write to a user holding 'current', when the
+// resource previously managed 'removed' and now manages 'managed'.
+func mergeUserAuthorities(current []authority, removed []authority, managed []authority, exclusive bool) []authority {
+	if exclusive {
+		return expandPermissionSets(managed, nil)
+	}
+
+	drop := map[authority]bool{}
+	for _, a := range removed {
+		drop[a] = true
+	}
+	for _, a := range managed {
+		delete(drop, a)
+	}
+
+	var kept []authority
+	for _, a := range current {
+		if !drop[a] {
+			kept = append(kept, a)
+		}
+	}
+	return expandPermissionSets(kept, []permissionSetDefinition{{Authorities: managed}})
+}
+
+// lookupAuthoritiesUser finds the user by pid when known, or else by username.
+func lookupAuthoritiesUser(ctx context.Context, c *smilecdr.Client, d *schema.ResourceData) (smilecdr.User, error) {
+	nodeId := d.Get("node_id").(string)
+	moduleId := d.Get("module_id").(string)
+
+	if pid := d.Get("pid").(int); pid != 0 {
+		return c.GetUser(ctx, nodeId, moduleId, pid)
+	}
+	return c.GetUserByUsername(ctx, nodeId, moduleId, d.Get("username").(string))
+}
+
+func writeUserAuthorities(ctx context.Context, d *schema.ResourceData, m interface{}, removed []authority, managed []authority) diag.Diagnostics {
+
+	c := m.(*smilecdr.Client)
+
+	user, err := lookupAuthoritiesUser(ctx, c, d)
+	if err != nil {
+		diags := diag.FromErr(err)
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Error reading user record",
+		})
+		return diags
+	}
+	if user.Pid == 0 {
+		return diag.Errorf("user not found in module %s/%s", d.Get("node_id").(string), d.Get("module_id").(string))
+	}
+
+	defer lockUserAuthorities(fmt.Sprintf("%s/%s/%d", user.NodeId, user.ModuleId, user.Pid))()
+
+	// Read the user again under the lock, as another resource may have just written it.
+	user, err = c.GetUser(ctx, user.NodeId, user.ModuleId, user.Pid)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	current := userAuthoritiesToAuthorities(user.Authorities)
+	user.Authorities = authoritiesToUserAuthorities(mergeUserAuthorities(current, removed, managed, d.Get("exclusive").(bool)))
+	user.Password = "" // never resend the stored password
+
+	_, err = c.PutUser(ctx, user)
+	if err != nil {
+		diags := diag.FromErr(err)
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Error updating user authorities",
+		})
+		return diags
+	}
+
+	d.SetId(fmt.Sprintf("%s/%s/%d", user.NodeId, user.ModuleId, user.Pid))
+	d.Set("pid", user.Pid)
+
+	return nil
+}
+
+func resourceUserAuthoritiesCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	managed := authoritiesFromSet(d.Get("authorities").(*schema.Set))
+
+	diags := writeUserAuthorities(ctx, d, m, nil, managed)
+	if diags.HasError() {
+		return diags
+	}
+
+	return resourceUserAuthoritiesRead(ctx, d, m)
+}
+
+func resourceUserAuthoritiesRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	c := m.(*smilecdr.Client)
+
+	user, err := c.GetUser(ctx, d.Get("node_id").(string), d.Get("module_id").(string), d.Get("pid").(int))
+	if smilecdr.IsNotFound(err) {
+		d.SetId("") // removed outside of Terraform
+		return nil
+	}
+	if err != nil {
+		diags := diag.FromErr(err)
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Error reading user record",
+		})
+		return diags
+	}
+
+	current := userAuthoritiesToAuthorities(user.Authorities)
+
+	// In additive mode, only the managed authorities are reported, so that a managed authority
+	// removed on the server shows as a change while those granted by others are ignored.
+	if !d.Get("exclusive").(bool) {
+		held := map[authority]bool{}
+		for _, a := range current {
+			held[a] = true
+		}
+		current = nil
+		for _, a := range authoritiesFromSet(d.Get("authorities").(*schema.Set)) {
+			if held[a] {
+				current = append(current, a)
+			}
+		}
+	}
+
+	d.Set("username", user.Username)
+	d.Set("authorities", flattenAuthorities(current))
+
+	return nil
+}
+
+func resourceUserAuthoritiesUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	old, new := d.GetChange("authorities")
+
+	diags := writeUserAuthorities(ctx, d, m, authoritiesFromSet(old.(*schema.Set)), authoritiesFromSet(new.(*schema.Set)))
+	if diags.HasError() {
+		return diags
+	}
+
+	return resourceUserAuthoritiesRead(ctx, d, m)
+}
+
+func resourceUserAuthoritiesDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+
+	managed := authoritiesFromSet(d.Get("authorities").(*schema.Set))
+
+	// Removing an exclusive resource leaves the user without authorities,
+	// and removing an additive one only revokes what it granted.
+	diags := writeUserAuthorities(ctx, d, m, managed, nil)
+	if diags.HasError() {
+		return diags
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+func resourceUserAuthoritiesImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+
+	parts := strings.Split(d.Id(), "/")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid import. supported import formats: {{nodeId}}/{{moduleId}}/{{pid}}")
+	}
+
+	pid, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid import. pid '%s' is not a number", parts[2])
+	}
+
+	d.Set("node_id", parts[0])
+	d.Set("module_id", parts[1])
+	d.Set("pid", pid)
+	d.Set("exclusive", true) // an imported resource takes ownership of every authority the user holds
+
+	diagnostics := resourceUserAuthoritiesRead(ctx, d, meta)
+	if diagnostics.HasError() {
+		return nil, errors.New(diagnostics[0].Summary)
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
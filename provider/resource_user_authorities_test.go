@@ -0,0 +1,161 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr/smilecdrtest"
+)
+
+func TestSmileCdrUserAuthorities(t *testing.T) {
+	username := "U_" + strings.ToUpper(acctest.RandString(8))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testUserAuthoritiesConfig_additive(username),
+				Check: resource.ComposeTestCheckFunc(
+					testUserExists("smilecdr_user_authorities.team_a"),
+					testUserExists("smilecdr_user_authorities.team_b"),
+					resource.TestCheckResourceAttr("smilecdr_user_authorities.team_a", "authorities.#", "1"),
+					resource.TestCheckResourceAttr("smilecdr_user_authorities.team_b", "authorities.#", "2"),
+				),
+			},
+		},
+	})
+}
+
+func testUserAuthoritiesConfig_additive(username string) string {
+
+	return fmt.Sprintf(`resource "smilecdr_user" "shared_service_account" {
+		node_id = "Master"
+		module_id = "local_security"
+  		username = "%s"
+		password = "Passw0rd"
+		family_name = "Shared"
+  		given_name = "Account"
+		service_account = true
+
+		lifecycle {
+			ignore_changes = [
+				password,
+				authorities,
+			]
+		}
+	}
+
+	resource "smilecdr_user_authorities" "team_a" {
+		username = smilecdr_user.shared_service_account.username
+
+		authorities {
+			permission = "FHIR_ALL_READ"
+		}
+	}
+
+	resource "smilecdr_user_authorities" "team_b" {
+		pid = smilecdr_user.shared_service_account.pid
+
+		authorities {
+			permission = "FHIR_READ_ALL_OF_TYPE"
+			argument   = "Observation"
+		}
+		authorities {
+			permission = "ROLE_FHIR_CLIENT"
+		}
+		depends_on = [smilecdr_user_authorities.team_a]
+	}`, username)
+}
+
+func TestUserAuthoritiesModes(t *testing.T) {
+	server := smilecdrtest.NewServer()
+	c := smilecdr.NewClient(context.Background(), server.Start(), "admin", "password")
+	defer server.Close()
+
+	held := func() []string {
+		var result []string
+		for _, a := range userAuthoritiesToAuthorities(server.Users[0].Authorities) {
+			result = append(result, a.String())
+		}
+		sort.Strings(result)
+		return result
+	}
+	destroy := func(r *schema.Resource, state *terraform.InstanceState) {
+		if _, diags := r.Apply(context.Background(), state, &terraform.InstanceDiff{Destroy: true}, c); diags.HasError() {
+			t.Fatalf("destroy failed: %+v", diags)
+		}
+	}
+	authorities := func(permissions ...string) []interface{} {
+		var result []interface{}
+		for _, permission := range permissions {
+			result = append(result, map[string]interface{}{"permission": permission})
+		}
+		return result
+	}
+
+	t.Run("additive", func(t *testing.T) {
+		server.Users = []smilecdr.User{{Pid: 1, NodeId: "Master", ModuleId: "local_security", Username: "shared",
+			Authorities: []smilecdr.UserAuthorities{{Permission: "ROLE_FHIR_CLIENT"}}}}
+
+		r := resourceUserAuthorities()
+		config := map[string]interface{}{"node_id": "Master", "username": "shared", "authorities": authorities("FHIR_ALL_READ")}
+		state := testApply(t, r, nil, config, c)
+		if got := held(); !reflect.DeepEqual(got, []string{"FHIR_ALL_READ", "ROLE_FHIR_CLIENT"}) {
+			t.Errorf("the user holds %v after create", got)
+		}
+		if state.Attributes["authorities.#"] != "1" {
+			t.Errorf("%s authorities are reported, those granted by others included", state.Attributes["authorities.#"])
+		}
+
+		config["authorities"] = authorities("FHIR_ALL_WRITE")
+		state = testApply(t, r, state, config, c)
+		if got := held(); !reflect.DeepEqual(got, []string{"FHIR_ALL_WRITE", "ROLE_FHIR_CLIENT"}) {
+			t.Errorf("the user holds %v after update", got)
+		}
+
+		destroy(r, state)
+		if got := held(); !reflect.DeepEqual(got, []string{"ROLE_FHIR_CLIENT"}) {
+			t.Errorf("the user holds %v after destroy", got)
+		}
+	})
+
+	t.Run("exclusive", func(t *testing.T) {
+		server.Users = []smilecdr.User{{Pid: 1, NodeId: "Master", ModuleId: "local_security", Username: "shared",
+			Authorities: []smilecdr.UserAuthorities{{Permission: "ROLE_FHIR_CLIENT"}}}}
+
+		r := resourceUserAuthorities()
+		config := map[string]interface{}{"node_id": "Master", "username": "shared", "exclusive": true, "authorities": authorities("FHIR_ALL_READ")}
+		state := testApply(t, r, nil, config, c)
+		if got := held(); !reflect.DeepEqual(got, []string{"FHIR_ALL_READ"}) {
+			t.Errorf("the user holds %v after create", got)
+		}
+
+		server.Users[0].Authorities = append(server.Users[0].Authorities, smilecdr.UserAuthorities{Permission: "ROLE_FHIR_CLIENT"})
+		state, diags := r.RefreshWithoutUpgrade(context.Background(), state, c)
+		if diags.HasError() {
+			t.Fatalf("refresh failed: %+v", diags)
+		}
+		state = testApply(t, r, state, config, c)
+		if got := held(); !reflect.DeepEqual(got, []string{"FHIR_ALL_READ"}) {
+			t.Errorf("a permission granted outside of Terraform is kept: %v", got)
+		}
+
+		destroy(r, state)
+		if got := held(); len(got) != 0 {
+			t.Errorf("the user holds %v after destroy", got)
+		}
+	})
+}
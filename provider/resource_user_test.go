@@ -4,13 +4,19 @@
 package provider
 
 import (
+	"context"
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr/smilecdrtest"
 )
 
 func TestSmileCdrUser(t *testing.T) {
@@ -28,6 +34,71 @@ func TestSmileCdrUser(t *testing.T) {
 	})
 }
 
+func TestSmileCdrUserInvalidPermissionArgument(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config:      testUserConfig_invalidArgument(),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`'Observation' is not a FHIR compartment type`),
+			},
+		},
+	})
+}
+
+func TestSmileCdrUserTwoFactorAuth(t *testing.T) {
+	username := "U_" + strings.ToUpper(acctest.RandString(8))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testUserConfig_twoFactorAuth(username, ""),
+				Check: resource.ComposeTestCheckFunc(
+					testUserExists("smilecdr_user.tfa_user"),
+					resource.TestCheckResourceAttr("smilecdr_user.tfa_user", "require_2fa", "true"),
+					resource.TestCheckResourceAttr("smilecdr_user.tfa_user", "2fa_enrolled", "false"),
+				),
+			},
+			{
+				Config: testUserConfig_twoFactorAuth(username, "helpdesk-ticket-1"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("smilecdr_user.tfa_user", "reset_2fa_trigger", "helpdesk-ticket-1"),
+					resource.TestCheckResourceAttr("smilecdr_user.tfa_user", "2fa_enrolled", "false"),
+				),
+			},
+		},
+	})
+}
+
+func TestSmileCdrUserUnlock(t *testing.T) {
+	username := "U_" + strings.ToUpper(acctest.RandString(8))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testUserConfig_lockout(username, true),
+				Check: resource.ComposeTestCheckFunc(
+					testUserExists("smilecdr_user.locked_user"),
+					resource.TestCheckResourceAttr("smilecdr_user.locked_user", "account_locked", "true"),
+				),
+			},
+			{
+				Config: testUserConfig_lockout(username, false),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("smilecdr_user.locked_user", "account_locked", "false"),
+					resource.TestCheckResourceAttr("smilecdr_user.locked_user", "failed_login_count", "0"),
+				),
+			},
+		},
+	})
+}
+
 func testUserConfig_basic() string {
 
 	username := "U_" + strings.ToUpper(acctest.RandString(8))
@@ -86,6 +157,60 @@ func testUserConfig_permissions() string {
 	}`, username)
 }
 
+func testUserConfig_invalidArgument() string {
+
+	username := "U_" + strings.ToUpper(acctest.RandString(8))
+
+	return fmt.Sprintf(`resource "smilecdr_user" "invalid_argument_user" {
+		node_id = "Master"
+		module_id = "local_security"
+  		username = "%s"
+		password = "Passw0rd"
+
+		authorities {
+			permission = "FHIR_READ_ALL_IN_COMPARTMENT"
+			argument   = "Observation/123"
+	    }
+	}`, username)
+}
+
+func testUserConfig_twoFactorAuth(username string, resetTrigger string) string {
+
+	return fmt.Sprintf(`resource "smilecdr_user" "tfa_user" {
+		node_id = "Master"
+		module_id = "local_security"
+  		username = "%s"
+		password = "Passw0rd"
+		service_account = true
+		require_2fa = true
+		reset_2fa_trigger = "%s"
+
+		lifecycle {
+			ignore_changes = [
+				password,
+			]
+		}
+	}`, username, resetTrigger)
+}
+
+func testUserConfig_lockout(username string, locked bool) string {
+
+	return fmt.Sprintf(`resource "smilecdr_user" "locked_user" {
+		node_id = "Master"
+		module_id = "local_security"
+  		username = "%s"
+		password = "Passw0rd"
+		account_locked = %t
+		lockout_policy = "enforce_unlocked"
+
+		lifecycle {
+			ignore_changes = [
+				password,
+			]
+		}
+	}`, username, locked)
+}
+
 func testUserExists(n string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[n]
@@ -103,3 +228,203 @@ func testUserExists(n string) resource.TestCheckFunc {
 		return nil
 	}
 }
+
+func TestUserBooleans(t *testing.T) {
+	server := smilecdrtest.NewServer()
+	c := smilecdr.NewClient(context.Background(), server.Start(), "admin", "password")
+	defer server.Close()
+
+	r := resourceUser()
+	config := map[string]interface{}{
+		"node_id":  "Master",
+		"username": "service-account",
+		"password": "Passw0rd!",
+	}
+
+	var state *terraform.InstanceState
+	for _, attribute := range testOptionalBools(r) {
+		for _, value := range []bool{false, true, false} {
+			config[attribute] = value
+			state = testApply(t, r, state, config, c)
+
+			user := server.Users[0]
+			stored, ok := testJSONField(t, user, attribute)
+			if attribute == "require_2fa" {
+				// Set through its own endpoint, which only a change calls.
+				stored, ok = server.TwoFactorAuthRequired[user.Pid], true
+			}
+			if !ok || stored != value {
+				t.Errorf("%s = %t did not reach the server, which holds %v", attribute, value, stored)
+			}
+			if state.Attributes[attribute] != strconv.FormatBool(value) {
+				t.Errorf("%s = %t is %s in the state", attribute, value, state.Attributes[attribute])
+			}
+		}
+	}
+
+	// Strings and lists removed from the configuration are cleared on the server.
+	values := map[string]interface{}{
+		"family_name": "Smith",
+		"given_name":  "Alice",
+		"authorities": []interface{}{map[string]interface{}{"permission": "FHIR_ALL_READ"}},
+	}
+	for attribute, value := range values {
+		config[attribute] = value
+	}
+	state = testApply(t, r, state, config, c)
+	for attribute := range values {
+		if stored, ok := testJSONField(t, server.Users[0], attribute); !ok || isEmptyJSONField(stored) {
+			t.Errorf("%s did not reach the server, which holds %v", attribute, stored)
+		}
+	}
+
+	for attribute := range values {
+		delete(config, attribute)
+	}
+	state = testApply(t, r, state, config, c)
+	for attribute := range values {
+		if stored, _ := testJSONField(t, server.Users[0], attribute); !isEmptyJSONField(stored) {
+			t.Errorf("%s removed from the configuration is still %v on the server", attribute, stored)
+		}
+	}
+	if diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), c); err != nil || (diff != nil && !diff.Empty()) {
+		t.Errorf("the cleared fields plan %v (%v)", diff, err)
+	}
+}
+
+func TestUserTwoFactorAuth(t *testing.T) {
+	server := smilecdrtest.NewServer()
+	c := smilecdr.NewClient(context.Background(), server.Start(), "admin", "password")
+	defer server.Close()
+
+	r := resourceUser()
+	config := map[string]interface{}{
+		"node_id":     "Master",
+		"username":    "clinician",
+		"password":    "Passw0rd!",
+		"require_2fa": true,
+	}
+	state := testApply(t, r, nil, config, c)
+	pid := server.Users[0].Pid
+	if !server.TwoFactorAuthRequired[pid] || state.Attributes["require_2fa"] != "true" {
+		t.Errorf("require_2fa is %v on the server and %s in the state", server.TwoFactorAuthRequired[pid], state.Attributes["require_2fa"])
+	}
+
+	// A requirement dropped on the server is planned back.
+	server.TwoFactorAuthRequired[pid] = false
+	state, diags := r.RefreshWithoutUpgrade(context.Background(), state, c)
+	if diags.HasError() || state.Attributes["require_2fa"] != "false" {
+		t.Fatalf("the requirement dropped on the server is refreshed as %s: %+v", state.Attributes["require_2fa"], diags)
+	}
+	state = testApply(t, r, state, config, c)
+	if !server.TwoFactorAuthRequired[pid] {
+		t.Error("the requirement dropped on the server is not set again")
+	}
+
+	config["reset_2fa_trigger"] = "2026-10-19"
+	server.Requests = nil
+	state = testApply(t, r, state, config, c)
+	reset := fmt.Sprintf("POST /user-management/Master/local_security/%d/two-factor-auth/reset", pid)
+	if !strings.Contains(strings.Join(server.Requests, "\n"), reset) {
+		t.Errorf("changing reset_2fa_trigger did not reset the key, requests were %v", server.Requests)
+	}
+
+	// Without the endpoint, only users required to enrol are warned about, on every refresh.
+	server.TwoFactorAuthUnavailable = true
+	if _, diags := r.RefreshWithoutUpgrade(context.Background(), state, c); diags.HasError() || len(diags) != 1 || diags[0].Severity != diag.Warning {
+		t.Errorf("a user required to enrol refreshes with %+v", diags)
+	}
+	server.TwoFactorAuthUnavailable = false
+	config["require_2fa"] = false
+	state = testApply(t, r, state, config, c)
+	server.TwoFactorAuthUnavailable = true
+	if _, diags := r.RefreshWithoutUpgrade(context.Background(), state, c); len(diags) != 0 {
+		t.Errorf("a user not required to enrol refreshes with %+v", diags)
+	}
+}
+
+func TestUserLockoutPolicy(t *testing.T) {
+	server := smilecdrtest.NewServer()
+	c := smilecdr.NewClient(context.Background(), server.Start(), "admin", "password")
+	defer server.Close()
+
+	r := resourceUser()
+	lock := func() {
+		server.Users[0].AccountLocked = smilecdr.Bool(true)
+		server.Users[0].FailedLoginCount = 5
+	}
+	refresh := func(state *terraform.InstanceState) *terraform.InstanceState {
+		state, diags := r.RefreshWithoutUpgrade(context.Background(), state, c)
+		if diags.HasError() {
+			t.Fatalf("refresh failed: %+v", diags)
+		}
+		return state
+	}
+
+	t.Run("enforce_unlocked", func(t *testing.T) {
+		server.Users = nil
+		config := map[string]interface{}{
+			"node_id":        "Master",
+			"username":       "clinician",
+			"password":       "Passw0rd!",
+			"lockout_policy": "enforce_unlocked",
+		}
+		state := testApply(t, r, nil, config, c)
+
+		lock()
+		state = refresh(state)
+		if state.Attributes["account_locked"] != "true" || state.Attributes["failed_login_count"] != "5" {
+			t.Errorf("the lock is refreshed as %s after %s failed logins", state.Attributes["account_locked"], state.Attributes["failed_login_count"])
+		}
+
+		server.Requests = nil
+		state = testApply(t, r, state, config, c)
+		pid := server.Users[0].Pid
+		unlock := fmt.Sprintf("POST /user-management/Master/local_security/%d/unlock", pid)
+		update := fmt.Sprintf("PUT /user-management/Master/local_security/%d", pid)
+		requests := strings.Join(server.Requests, "\n")
+		if !strings.Contains(requests, unlock) || strings.Contains(requests, update) {
+			t.Errorf("unlocking made the requests %v", server.Requests)
+		}
+		if smilecdr.BoolValue(server.Users[0].AccountLocked) || state.Attributes["account_locked"] != "false" {
+			t.Error("the account is still locked")
+		}
+	})
+
+	t.Run("ignore_server_locks", func(t *testing.T) {
+		server.Users = nil
+		config := map[string]interface{}{
+			"node_id":        "Master",
+			"username":       "clinician",
+			"password":       "Passw0rd!",
+			"lockout_policy": "ignore_server_locks",
+		}
+		state := testApply(t, r, nil, config, c)
+
+		lock()
+		state = refresh(state)
+		if state.Attributes["account_locked"] != "false" {
+			t.Error("the lock placed by the server is reported")
+		}
+		diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff != nil && !diff.Empty() {
+			t.Errorf("the lock placed by the server plans %v", diff.Attributes)
+		}
+		if !smilecdr.BoolValue(server.Users[0].AccountLocked) {
+			t.Error("the lock placed by the server was lifted")
+		}
+
+		// Updating another field leaves the lock alone.
+		config["given_name"] = "Alice"
+		state = testApply(t, r, state, config, c)
+		if server.Users[0].GivenName != "Alice" || !smilecdr.BoolValue(server.Users[0].AccountLocked) {
+			t.Errorf("updating the given name lifted the lock placed by the server: %+v", server.Users[0])
+		}
+		if state.Attributes["account_locked"] != "false" {
+			t.Error("the lock placed by the server is reported")
+		}
+	})
+}
@@ -0,0 +1,173 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// scriptSource is an attribute taking the path of a local file, whose content is sent to the server in
+// place of an inline script attribute. Only a hash of the content is kept in the state.
+type scriptSource struct {
+	attribute     string                        // the path of the local file
+	textAttribute string                        // the inline script attribute it takes the place of
+	validate      schema.SchemaValidateDiagFunc // the validation of the script, as of the inline script attribute
+}
+
+func (source scriptSource) hashAttribute() string {
+	return source.attribute + "_sha256"
+}
+
+// scriptSourcesSchema adds the source and hash attributes of the sources to a schema which already holds
+// their inline script attributes, and makes the two ways of setting a script conflict.
+func scriptSourcesSchema(sources []scriptSource, s map[string]*schema.Schema) map[string]*schema.Schema {
+	for _, source := range sources {
+		text, ok := s[source.textAttribute]
+		if !ok {
+			panic(fmt.Sprintf("script source %s takes the place of %s, which is not in the schema", source.attribute, source.textAttribute))
+		}
+		text.ConflictsWith = append(text.ConflictsWith, source.attribute)
+
+		s[source.attribute] = &schema.Schema{
+			Type:             schema.TypeString,
+			Optional:         true,
+			ConflictsWith:    []string{source.textAttribute},
+			ValidateDiagFunc: validateScriptSource(source.validate),
+			Description:      fmt.Sprintf("The path of a local file holding the script, read by the provider at plan time and sent as `%s`. Line endings and trailing whitespace are normalized, and only a hash of the content is kept in the state.", source.textAttribute),
+		}
+		s[source.hashAttribute()] = &schema.Schema{
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: fmt.Sprintf("The SHA-256 hash of the normalized script read from `%s`. It changes when the content of the file, or the script on the server, changes.", source.attribute),
+		}
+	}
+	return s
+}
+
+// normalizeScript normalizes the line endings of a script to LF, and trims the trailing whitespace of
+// its lines and the blank lines around it, so that a checkout on Windows hashes like any other.
+func normalizeScript(script string) string {
+	script = strings.TrimPrefix(script, "\ufeff")
+	script = strings.ReplaceAll(script, "\r\n", "\n")
+	script = strings.ReplaceAll(script, "\r", "\n")
+
+	lines := strings.Split(script, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// scriptHash returns the hex SHA-256 hash of the normalized script.
+func scriptHash(script string) string {
+	sum := sha256.Sum256([]byte(normalizeScript(script)))
+	return hex.EncodeToString(sum[:])
+}
+
+// readScriptSource reads the normalized script of a local file.
+func readScriptSource(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to read the script: %w", err)
+	}
+	return normalizeScript(string(content)), nil
+}
+
+// validateScriptSource validates the script of the local file named by a source attribute. Files that
+// cannot be read are left to scriptSourcesDiff, which fails the plan.
+func validateScriptSource(validate schema.SchemaValidateDiagFunc) schema.SchemaValidateDiagFunc {
+	if validate == nil {
+		return nil
+	}
+	return func(i interface{}, k cty.Path) diag.Diagnostics {
+		path, ok := i.(string)
+		if !ok || path == "" {
+			return nil
+		}
+		script, err := readScriptSource(path)
+		if err != nil {
+			return nil
+		}
+		diags := validate(script, k)
+		for i := range diags {
+			diags[i].Detail = path + ": " + diags[i].Detail
+		}
+		return diags
+	}
+}
+
+// expandScriptSource returns the script of the local file named by the source attribute, when it is set.
+// The file is read again at apply time, so a file changed since the plan fails instead of uploading a
+// script whose hash differs from the planned one.
+func expandScriptSource(d *schema.ResourceData, source scriptSource) (string, bool, error) {
+	path, ok := d.GetOk(source.attribute)
+	if !ok {
+		return "", false, nil
+	}
+	script, err := readScriptSource(path.(string))
+	if err != nil {
+		return "", false, fmt.Errorf("%s: %w", source.attribute, err)
+	}
+	if planned := d.Get(source.hashAttribute()).(string); planned != "" && scriptHash(script) != planned {
+		return "", false, fmt.Errorf("%s: the file %s changed since the plan, plan again", source.attribute, path)
+	}
+	return script, true, nil
+}
+
+// flattenScriptSource sets the script read from the server: as the hash of the source when it is set,
+// or else as the inline script attribute.
+func flattenScriptSource(d *schema.ResourceData, source scriptSource, script string) {
+	if _, ok := d.GetOk(source.attribute); ok {
+		d.Set(source.hashAttribute(), scriptHash(script))
+		return
+	}
+	d.Set(source.textAttribute, script)
+	d.Set(source.hashAttribute(), "")
+}
+
+// scriptSourcesDiff reads the local files of the sources at plan time, and plans a new hash when their
+// content no longer matches the hash in the state. Files that cannot be read fail the plan.
+func scriptSourcesDiff(sources []scriptSource) schema.CustomizeDiffFunc {
+	return func(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+		var errs []string
+		for _, source := range sources {
+			if !d.NewValueKnown(source.attribute) {
+				if err := d.SetNewComputed(source.hashAttribute()); err != nil {
+					return err
+				}
+				continue
+			}
+
+			hash := ""
+			if path, ok := d.GetOk(source.attribute); ok {
+				script, err := readScriptSource(path.(string))
+				if err != nil {
+					errs = append(errs, fmt.Sprintf("%s: %s", source.attribute, err.Error()))
+					continue
+				}
+				hash = scriptHash(script)
+			}
+			if hash != d.Get(source.hashAttribute()).(string) {
+				if err := d.SetNew(source.hashAttribute(), hash); err != nil {
+					return err
+				}
+			}
+		}
+
+		if len(errs) > 0 {
+			return errors.New(strings.Join(errs, "\n"))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/zedwerks/terraform-smilecdr/provider/helper/validations"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr/smilecdrtest"
+)
+
+func TestNormalizeScript(t *testing.T) {
+	const expected = "function onAuthenticateSuccess(theOutcome) {\n\treturn theOutcome;\n}"
+
+	for _, script := range []string{
+		expected,
+		expected + "\n",
+		"\n\n" + expected + "\n\n\n",
+		strings.ReplaceAll(expected, "\n", "\r\n") + "\r\n",
+		strings.ReplaceAll(expected, "\n", "\r"),
+		"\ufeff" + expected,
+		strings.ReplaceAll(expected, "\n", "  \n") + " \t",
+	} {
+		if actual := normalizeScript(script); actual != expected {
+			t.Errorf("%q normalized to %q, expected %q", script, actual, expected)
+		}
+		if scriptHash(script) != scriptHash(expected) {
+			t.Errorf("%q does not hash like %q", script, expected)
+		}
+	}
+
+	if scriptHash(expected) == scriptHash(strings.Replace(expected, "theOutcome;", "null;", 1)) {
+		t.Error("scripts of a different content hash alike")
+	}
+}
+
+// TestScriptSources applies an identity provider with its script read from a local file, and checks
+// that the plan changes with the content of the file, and only with it.
+func TestScriptSources(t *testing.T) {
+	server := smilecdrtest.NewServer()
+	c := smilecdr.NewClient(context.Background(), server.Start(), "admin", "password")
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "on_auth_success.js")
+	write := func(script string) {
+		if err := os.WriteFile(path, []byte(script), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := resourceOpenIdIdentityProvider()
+	config := map[string]interface{}{
+		"node_id":                       "Master",
+		"issuer":                        "https://example.com/issuer",
+		"federation_auth_script_source": path,
+	}
+	plan := func(state *terraform.InstanceState) *terraform.InstanceDiff {
+		diff, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), c)
+		if err != nil {
+			t.Fatalf("plan failed: %s", err)
+		}
+		return diff
+	}
+
+	write("function onAuthenticateSuccess(theOutcome) {\n  return theOutcome;\n}\n")
+	state := testApply(t, r, nil, config, c)
+
+	script := server.IdentityProviders[0].FederationAuthScriptText
+	if script != "function onAuthenticateSuccess(theOutcome) {\n  return theOutcome;\n}" {
+		t.Errorf("the server holds the script %q", script)
+	}
+	if state.Attributes["federation_auth_script_source_sha256"] != scriptHash(script) {
+		t.Errorf("the state holds the hash %q", state.Attributes["federation_auth_script_source_sha256"])
+	}
+	if state.Attributes["federation_auth_script_text"] != "" {
+		t.Errorf("the state holds the script %q", state.Attributes["federation_auth_script_text"])
+	}
+
+	write("function onAuthenticateSuccess(theOutcome) {  \r\n  return theOutcome;\r\n}\r\n\r\n")
+	if diff := plan(state); diff != nil && !diff.Empty() {
+		t.Errorf("a checkout with CRLF line endings plans %v", diff.Attributes)
+	}
+
+	write("function onAuthenticateSuccess(theOutcome) {\n  return null;\n}\n")
+	diff := plan(state)
+	if diff == nil || diff.Attributes["federation_auth_script_source_sha256"] == nil {
+		t.Fatalf("a change of the script does not plan a new hash")
+	}
+	state = testApply(t, r, state, config, c)
+	if script := server.IdentityProviders[0].FederationAuthScriptText; !strings.Contains(script, "return null;") {
+		t.Errorf("the change of the script did not reach the server, which holds %q", script)
+	}
+
+	write("function onAuthenticateSuccess(theOutcome) {\n  return undefined;\n}\n")
+	diff = plan(state)
+	write("function onAuthenticateSuccess(theOutcome) {\n  throw 'changed after the plan';\n}\n")
+	if _, diags := r.Apply(context.Background(), state, diff, c); !diags.HasError() || !strings.Contains(diags[0].Summary, "changed since the plan") {
+		t.Errorf("a script changed after the plan applies with %+v", diags)
+	}
+	if script := server.IdentityProviders[0].FederationAuthScriptText; !strings.Contains(script, "return null;") {
+		t.Errorf("a script changed after the plan reached the server, which holds %q", script)
+	}
+
+	server.IdentityProviders[0].FederationAuthScriptText = "// changed on the server"
+	state, diags := r.RefreshWithoutUpgrade(context.Background(), state, c)
+	if diags.HasError() {
+		t.Fatalf("refresh failed: %+v", diags)
+	}
+	if diff := plan(state); diff == nil || diff.Attributes["federation_auth_script_source_sha256"] == nil {
+		t.Errorf("a change of the script on the server does not plan a new hash")
+	}
+
+	config["federation_auth_script_source"] = filepath.Join(t.TempDir(), "missing.js")
+	if _, err := r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), c); err == nil || !strings.Contains(err.Error(), "federation_auth_script_source: unable to read the script") {
+		t.Errorf("a missing script plans with %v", err)
+	}
+}
+
+func TestScriptSyntax(t *testing.T) {
+	validate := validations.IsScript("SMART inbound security callback", "authenticate-success")
+	path := cty.GetAttrPath("callback_script_text")
+
+	diags := validate("function onAuthenticateSuccess(theOutcome, theOutcomeFactory, theContext) {\n  return theOutcome;\n}", path)
+	if len(diags) > 0 {
+		t.Errorf("a valid script has diagnostics %+v", diags)
+	}
+	diags = validate("var onAuthenticateSuccess = function(theOutcome) {\n  return theOutcome;\n};", path)
+	if len(diags) > 0 {
+		t.Errorf("a script binding its callback to a variable has diagnostics %+v", diags)
+	}
+
+	diags = validate("function onAuthenticateSuccess(theOutcome) {\n  if (theOutcome {\n    return theOutcome;\n  }\n}", path)
+	if !diags.HasError() || !strings.Contains(diags[0].Detail, "line 2, column 18") {
+		t.Errorf("a syntax error has diagnostics %+v", diags)
+	}
+
+	diags = validate("function onAuthenticationSuccess(theOutcome) {\n  return theOutcome;\n}", path)
+	if len(diags) != 1 || diags[0].Severity != diag.Warning || !strings.Contains(diags[0].Detail, "onAuthenticateSuccess") {
+		t.Errorf("a script without its callback has diagnostics %+v", diags)
+	}
+
+	// Any of the functions of a hook will do, and a hook running the script as a whole expects none.
+	contextSelection := "function onSmartLoginPreContextSelection(theUserSession, theContextSelectionChoices) {}"
+	if diags := validations.IsScript("SMART post-authorize callback", "post-authorize")(contextSelection, path); len(diags) > 0 {
+		t.Errorf("a context selection script has diagnostics %+v", diags)
+	}
+	if diags := validations.IsScript("CODAP authorization", "codap-authorization")("var allowed = true;", path); len(diags) > 0 {
+		t.Errorf("a CODAP script has diagnostics %+v", diags)
+	}
+}
+
+// TestExampleScripts checks the example scripts against the validation of the attributes they are used in.
+func TestExampleScripts(t *testing.T) {
+	scripts := map[string]schema.SchemaValidateDiagFunc{
+		"smart_outbound_security/post_authorize_callback.js": moduleOptionValidation(smartOutboundOptions, "smart_callback_post_authorize_script_text"),
+		"smart_outbound_security/codap_authorization.js":     moduleOptionValidation(smartOutboundOptions, "codap_authorization_script_text"),
+		"smart_inbound_security/authentication_callback.js":  moduleOptionValidation(smartInboundOptions, "callback_script_text"),
+		"local_inbound_security/authentication_callback.js":  moduleOptionValidation(smartInboundOptions, "callback_script_text"),
+		"idp/on_auth_success.js":                             openIdIdentityProviderScriptSources[0].validate,
+		"idp/user_mapping.js":                                openIdIdentityProviderScriptSources[1].validate,
+	}
+	for name, validate := range scripts {
+		script, err := readScriptSource(filepath.Join("..", "example", "js", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diags := validate(script, cty.Path{}); len(diags) > 0 {
+			t.Errorf("%s: %+v", name, diags)
+		}
+	}
+}
+
+func moduleOptionValidation(options []moduleOption, attribute string) schema.SchemaValidateDiagFunc {
+	for _, option := range options {
+		if option.attribute == attribute {
+			return option.validate
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+)
+
+func validateServerVersion(v interface{}, path cty.Path) diag.Diagnostics {
+	if _, err := smilecdr.ParseVersion(v.(string)); err != nil {
+		return diag.Diagnostics{{Severity: diag.Error, Summary: err.Error(), AttributePath: path}}
+	}
+	return nil
+}
+
+// serverVersion returns the configured server version, or else detects it from the server. A server that
+// cannot be reached, or does not report its version, leaves the version unknown.
+func serverVersion(ctx context.Context, c *smilecdr.Client, configured string) *smilecdr.Version {
+	if configured != "" {
+		version, _ := smilecdr.ParseVersion(configured)
+		return &version
+	}
+
+	version, err := c.GetServerVersion(ctx)
+	if err != nil {
+		tflog.Warn(ctx, "Unable to detect the Smile CDR server version, options are not checked against it: "+err.Error())
+		return nil
+	}
+	tflog.Info(ctx, "Detected Smile CDR server version "+version.String())
+	return &version
+}
+
+// validateMinimumVersionsDiff checks the options set in the configuration against the version of the
+// server, for new resources and changed options. Unsupported options fail the plan when the provider sets
+// `unsupported_options = "error"`. Otherwise they are logged, as a plan cannot warn, and reported as
+// warnings by the apply, see unsupportedOptionsWarnings.
+func validateMinimumVersionsDiff(options []moduleOption) schema.CustomizeDiffFunc {
+	return func(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+		c, ok := m.(*smilecdr.Client)
+		if !ok || c == nil || c.ServerVersion == nil {
+			return nil
+		}
+
+		unsupported := unsupportedOptions(d, *c.ServerVersion, options)
+		if len(unsupported) == 0 {
+			return nil
+		}
+		if c.RejectUnsupportedOptions {
+			return errors.New(strings.Join(unsupported, "\n"))
+		}
+		for _, message := range unsupported {
+			tflog.Warn(ctx, message)
+		}
+		return nil
+	}
+}
+
+// unsupportedOptionsWarnings reports the options that the version of the server does not support as
+// warnings of a create or update, when the provider does not reject them.
+func unsupportedOptionsWarnings(d *schema.ResourceData, m interface{}, options []moduleOption) diag.Diagnostics {
+	c, ok := m.(*smilecdr.Client)
+	if !ok || c == nil || c.ServerVersion == nil || c.RejectUnsupportedOptions {
+		return nil
+	}
+
+	var diags diag.Diagnostics
+	for _, message := range unsupportedOptions(d, *c.ServerVersion, options) {
+		attribute := strings.SplitN(message, ":", 2)[0]
+		diags = append(diags, diag.Diagnostic{
+			Severity:      diag.Warning,
+			Summary:       "Option not supported by the server version",
+			Detail:        message + ". The server may ignore or reject it; set unsupported_options = \"error\" in the provider to fail the plan instead.",
+			AttributePath: cty.GetAttrPath(attribute),
+		})
+	}
+	return diags
+}
+
+// unsupportedOptions returns the options, or their script sources, sent to the server by a new resource
+// or changed by an update although the version of the server does not support them. Options set to false
+// or 0 in the configuration are sent, while removed options are reset rather than set, see moduleOptionOk.
+func unsupportedOptions(d moduleOptionData, version smilecdr.Version, options []moduleOption) []string {
+	var unsupported []string
+	for _, option := range options {
+		if option.minimumVersion == "" {
+			continue
+		}
+		minimum, _ := smilecdr.ParseVersion(option.minimumVersion)
+		if version.AtLeast(minimum) {
+			continue
+		}
+		attributes := []moduleOption{option}
+		if option.source != "" {
+			attributes = append(attributes, moduleOption{attribute: option.source})
+		}
+		for _, attribute := range attributes {
+			if optionSent(d, attribute) {
+				unsupported = append(unsupported, fmt.Sprintf("%s: requires Smile CDR %s or later, the server runs %s", attribute.attribute, minimum, version))
+			}
+		}
+	}
+	sort.Strings(unsupported)
+	return unsupported
+}
+
+// optionSent reports whether the attribute of an option is set by the configuration, and is new or changed.
+func optionSent(d moduleOptionData, option moduleOption) bool {
+	if v, ok := moduleOptionOk(d, option); !ok || v == nil {
+		return false
+	}
+	if d.Id() != "" && !d.HasChange(option.attribute) {
+		// An unchanged value is new only when the prior state, if there is one, held none, e.g. false.
+		if state := d.GetRawState(); state.IsNull() || rawAttributeSet(state, option.attribute) {
+			return false
+		}
+	}
+	if diff, ok := d.(*schema.ResourceDiff); ok && !diff.NewValueKnown(option.attribute) {
+		return false
+	}
+	return true
+}
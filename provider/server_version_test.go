@@ -0,0 +1,119 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package provider
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+	"github.com/zedwerks/terraform-smilecdr/smilecdr/smilecdrtest"
+)
+
+func TestServerVersion(t *testing.T) {
+	version, err := smilecdr.ParseVersion("2023.02.R03")
+	if err != nil || version.String() != "2023.02.R03" {
+		t.Fatalf("unexpected version %v, %v", version, err)
+	}
+	for minimum, expected := range map[string]bool{"2022.11": true, "2023.02": true, "2023.05": false, "2024.02": false} {
+		if v, _ := smilecdr.ParseVersion(minimum); version.AtLeast(v) != expected {
+			t.Errorf("expected %s at least %s to be %t", version, minimum, expected)
+		}
+	}
+	for _, invalid := range []string{"", "2023", "2023.13", "latest"} {
+		if _, err := smilecdr.ParseVersion(invalid); err == nil {
+			t.Errorf("expected %q to be invalid", invalid)
+		}
+	}
+}
+
+func TestSmartOutboundSecurityUnsupportedOptions(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: `provider "smilecdr" {
+					server_version      = "2022.05.R02"
+					unsupported_options = "error"
+				}
+				` + testSmartOutboundConfig(),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`oidc_federate_mode_enabled: requires Smile CDR 2022.11 or later, the server runs 2022.05.R02`),
+			},
+		},
+	})
+}
+
+func TestUnsupportedOptionsModes(t *testing.T) {
+	server := smilecdrtest.NewServer()
+	baseUrl := server.Start()
+	defer server.Close()
+
+	configure := func(mode string) *smilecdr.Client {
+		p := Provider()
+		diags := p.Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{
+			"base_url":            baseUrl,
+			"username":            "admin",
+			"password":            "password",
+			"server_version":      "2022.05.R02",
+			"unsupported_options": mode,
+		}))
+		if diags.HasError() {
+			t.Fatalf("configure failed: %+v", diags)
+		}
+		return p.Meta().(*smilecdr.Client)
+	}
+
+	r := resourceSmartOutboundSecurity()
+	config := map[string]interface{}{
+		"node_id":                    "Master",
+		"module_id":                  "smart_auth",
+		"http_listener_port":         9200,
+		"oidc_issuer_url":            "https://auth.example.org",
+		"oidc_federate_mode_enabled": true,
+	}
+	message := "oidc_federate_mode_enabled: requires Smile CDR 2022.11 or later, the server runs 2022.05.R02"
+
+	_, err := r.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(config), configure("error"))
+	if err == nil || err.Error() != message {
+		t.Errorf("expected the plan to fail with %q, got %v", message, err)
+	}
+	if len(server.Modules) != 0 {
+		t.Errorf("a rejected option was sent to the server: %+v", server.Modules)
+	}
+
+	// Set to false, the option is sent too.
+	config["oidc_federate_mode_enabled"] = false
+	_, err = r.Diff(context.Background(), testRawConfigState(r, config), terraform.NewResourceConfigRaw(config), configure("error"))
+	if err == nil || err.Error() != message {
+		t.Errorf("expected the plan of a false option to fail with %q, got %v", message, err)
+	}
+	config["oidc_federate_mode_enabled"] = true
+
+	c := configure("warn")
+	diff, err := r.Diff(context.Background(), nil, terraform.NewResourceConfigRaw(config), c)
+	if err != nil {
+		t.Fatalf("plan failed: %s", err)
+	}
+	state, diags := r.Apply(context.Background(), nil, diff, c)
+	if diags.HasError() || len(diags) != 1 || diags[0].Severity != diag.Warning || !strings.Contains(diags[0].Detail, message) {
+		t.Errorf("expected a warning about the unsupported option, got %+v", diags)
+	}
+
+	// An update warns about the options it changes only.
+	config["oidc_issuer_url"] = "https://smart.example.org"
+	diff, err = r.Diff(context.Background(), state, terraform.NewResourceConfigRaw(config), c)
+	if err != nil {
+		t.Fatalf("plan failed: %s", err)
+	}
+	if _, diags := r.Apply(context.Background(), state, diff, c); len(diags) != 0 {
+		t.Errorf("expected no warning for an unchanged option, got %+v", diags)
+	}
+}
@@ -6,11 +6,14 @@ package smilecdr
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/base64"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"sync"
 
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
@@ -20,6 +23,79 @@ type Client struct {
 	authHeader string
 	httpClient *http.Client
 	debug      bool
+
+	// When set, permissions are validated against the catalog of the connected server
+	// instead of the catalog built into the provider.
+	ServerPermissionCatalog bool
+
+	// The node of resources that do not set one.
+	DefaultNodeId string
+
+	// The version of the connected server, nil when it is not known.
+	ServerVersion *Version
+
+	// When set, options the server version does not support fail the plan instead of logging a warning.
+	RejectUnsupportedOptions bool
+
+	permissionsOnce sync.Once
+	permissions     []PermissionDefinition
+	permissionsErr  error
+}
+
+const (
+	AuthMethodBasic  = "basic"
+	AuthMethodBearer = "bearer"
+)
+
+// ClientConfig holds the connection settings of a client, for connections beyond basic authentication
+// over the default TLS settings.
+type ClientConfig struct {
+	BaseUrl               string
+	AuthMethod            string // AuthMethodBasic (default) or AuthMethodBearer
+	Username              string
+	Password              string
+	Token                 string
+	CACertFile            string // PEM file of the certificate authorities to trust, in addition to the system ones
+	TLSInsecureSkipVerify bool
+}
+
+func NewClientFromConfig(ctx context.Context, config ClientConfig) (*Client, error) {
+	var c *Client
+
+	switch config.AuthMethod {
+	case "", AuthMethodBasic:
+		c = NewClient(ctx, config.BaseUrl, config.Username, config.Password)
+	case AuthMethodBearer:
+		c = NewClient(ctx, config.BaseUrl, "", "")
+		c.authHeader = "Bearer " + config.Token
+	default:
+		return nil, fmt.Errorf("unknown auth method %q, expected %s or %s", config.AuthMethod, AuthMethodBasic, AuthMethodBearer)
+	}
+
+	if config.CACertFile == "" && !config.TLSInsecureSkipVerify {
+		return c, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.TLSInsecureSkipVerify}
+	if config.CACertFile != "" {
+		pem, err := os.ReadFile(config.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA certificates: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificate found in %s", config.CACertFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	c.httpClient = &http.Client{Transport: transport}
+
+	return c, nil
 }
 
 func NewClient(ctx context.Context, baseUrl string, username string, password string) *Client {
@@ -67,7 +143,7 @@ func (c *Client) Get(ctx context.Context, endpoint string) ([]byte, error) {
 	if resp.StatusCode != http.StatusOK {
 		errMsg := fmt.Sprintf("Http GET: received non-200 OK status code: %d", resp.StatusCode)
 		tflog.Error(ctx, errMsg)
-		return nil, err
+		return nil, newApiError(http.MethodGet, endpoint, resp)
 	}
 
 	rBody, err := io.ReadAll(resp.Body)
@@ -105,7 +181,7 @@ func (c *Client) Post(ctx context.Context, endpoint string, body []byte) ([]byte
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent {
 		errMsg := fmt.Sprintf("Http POST: Expecting 200, 201 or 204. Received: %d", resp.StatusCode)
 		tflog.Info(ctx, errMsg)
-		return nil, err
+		return nil, newApiError(http.MethodPost, endpoint, resp)
 	}
 
 	var rBody []byte = nil
@@ -143,8 +219,7 @@ func (c *Client) Put(ctx context.Context, endpoint string, body []byte) ([]byte,
 
 	if resp.StatusCode != http.StatusOK {
 		fmt.Printf("[WARN] Http PUT: Received non-200 status code: %d", resp.StatusCode)
-		// Handle the error condition here
-		return nil, err
+		return nil, newApiError(http.MethodPut, endpoint, resp)
 	}
 
 	var rBody []byte = nil
@@ -179,7 +254,7 @@ func (c *Client) Delete(ctx context.Context, endpoint string) ([]byte, error) {
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
 		fmt.Printf("[WARN] Http DELETE: Expected 200, or 204, instead received status code: %d", resp.StatusCode)
-		return nil, err
+		return nil, newApiError(http.MethodDelete, endpoint, resp)
 	}
 
 	rBody, err := io.ReadAll(resp.Body)
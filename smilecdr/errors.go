@@ -0,0 +1,87 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package smilecdr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ApiError is returned for any response of the Admin JSON API outside of the expected status codes.
+// Message holds the explanation given by the server, when there is one.
+type ApiError struct {
+	Method     string
+	Endpoint   string
+	StatusCode int
+	Message    string
+}
+
+func (e *ApiError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s %s: HTTP %d %s", e.Method, e.Endpoint, e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("%s %s: HTTP %d %s: %s", e.Method, e.Endpoint, e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// IsNotFound reports whether err is an ApiError for a missing resource.
+func IsNotFound(err error) bool {
+	var apiErr *ApiError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// Smile CDR describes errors either with a plain JSON message, or with a FHIR OperationOutcome.
+type errorResponse struct {
+	Message string `json:"message,omitempty"`
+	Error   string `json:"error,omitempty"`
+	Issue   []struct {
+		Diagnostics string `json:"diagnostics,omitempty"`
+	} `json:"issue,omitempty"`
+}
+
+const maxErrorBodyLength = 512
+
+func newApiError(method string, endpoint string, resp *http.Response) *ApiError {
+	apiErr := &ApiError{
+		Method:     method,
+		Endpoint:   endpoint,
+		StatusCode: resp.StatusCode,
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil || len(body) == 0 {
+		return apiErr
+	}
+
+	var parsed errorResponse
+	if json.Unmarshal(body, &parsed) == nil {
+		var messages []string
+		if parsed.Message != "" {
+			messages = append(messages, parsed.Message)
+		}
+		if parsed.Error != "" {
+			messages = append(messages, parsed.Error)
+		}
+		for _, issue := range parsed.Issue {
+			if issue.Diagnostics != "" {
+				messages = append(messages, issue.Diagnostics)
+			}
+		}
+		if len(messages) > 0 {
+			apiErr.Message = strings.Join(messages, "; ")
+			return apiErr
+		}
+	}
+
+	message := strings.TrimSpace(string(body))
+	if len(message) > maxErrorBodyLength {
+		message = message[:maxErrorBodyLength] + "..."
+	}
+	apiErr.Message = message
+
+	return apiErr
+}
@@ -0,0 +1,167 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package smilecdr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+)
+
+// InventoryVersion is the version of the inventory document format. It is increased whenever
+// a change to the document would prevent an older reader from using it. Version 2 added `nodes`.
+const InventoryVersion = 2
+
+// Inventory is a document holding the objects managed through the Admin JSON API of a server,
+// as returned by the API, so that servers can be compared or restored without a connection.
+type Inventory struct {
+	Version           int                      `json:"version"`
+	CreatedAt         string                   `json:"createdAt,omitempty"`
+	BaseUrl           string                   `json:"baseUrl,omitempty"`
+	NodeId            string                   `json:"nodeId,omitempty"`
+	Modules           []ModuleConfig           `json:"modules"`
+	OpenIdClients     []OpenIdClient           `json:"openIdClients"`
+	IdentityProviders []OpenIdIdentityProvider `json:"identityProviders"`
+	Users             []User                   `json:"users,omitempty"`
+	// Nodes holds an inventory per node when the document covers several nodes of a cluster,
+	// in place of the objects above.
+	Nodes []Inventory `json:"nodes,omitempty"`
+}
+
+// ClusterInventory gathers the inventories of several nodes into a single document. The inventory of
+// a single node is returned as is.
+func ClusterInventory(nodes []Inventory) Inventory {
+	if len(nodes) == 1 {
+		return nodes[0]
+	}
+	inventory := Inventory{Version: InventoryVersion, Nodes: nodes}
+	if len(nodes) > 0 {
+		inventory.CreatedAt, inventory.BaseUrl = nodes[0].CreatedAt, nodes[0].BaseUrl
+	}
+	return inventory
+}
+
+// NodeInventories returns the inventory of each node held by the document.
+func (inventory Inventory) NodeInventories() []Inventory {
+	if len(inventory.Nodes) > 0 {
+		return inventory.Nodes
+	}
+	return []Inventory{inventory}
+}
+
+// GetInventory reads the module configs, OpenID clients and identity providers of a node of the server.
+func (smilecdr *Client) GetInventory(ctx context.Context, nodeId string) (Inventory, error) {
+	inventory := Inventory{
+		Version:   InventoryVersion,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		BaseUrl:   smilecdr.baseUrl,
+		NodeId:    nodeId,
+	}
+
+	modules, err := smilecdr.GetNodeModuleConfigs(ctx, nodeId)
+	if err != nil {
+		return inventory, fmt.Errorf("reading module configs: %w", err)
+	}
+	inventory.Modules = modules
+
+	clients, err := smilecdr.GetOpenIdClients(ctx)
+	if err != nil {
+		return inventory, fmt.Errorf("reading OpenID clients: %w", err)
+	}
+	inventory.OpenIdClients = []OpenIdClient{}
+	for _, client := range clients {
+		if client.NodeId == nodeId {
+			inventory.OpenIdClients = append(inventory.OpenIdClients, client)
+		}
+	}
+
+	providers, err := smilecdr.GetOpenIdIdentityProviders(ctx)
+	if err != nil {
+		return inventory, fmt.Errorf("reading OpenID identity providers: %w", err)
+	}
+	inventory.IdentityProviders = []OpenIdIdentityProvider{}
+	for _, provider := range providers {
+		if provider.NodeId == nodeId {
+			inventory.IdentityProviders = append(inventory.IdentityProviders, provider)
+		}
+	}
+
+	return inventory, nil
+}
+
+// credentialNamePattern matches the names of the fields and module options holding credentials.
+var credentialNamePattern = regexp.MustCompile(`(?i)(password|secret)`)
+
+// IsCredentialName reports whether a field or module option of that name holds a credential, e.g.
+// `openid.signing.keystore_password` or `clientSecrets`.
+func IsCredentialName(name string) bool {
+	return credentialNamePattern.MatchString(name)
+}
+
+// RemoveCredentials blanks the user passwords, client secret values, identity provider client
+// secrets and credential module options of the inventory, keeping everything else about them, e.g.
+// the expiry of client secrets. The objects are copied first, so that a copy of the inventory made
+// before keeps its credentials.
+func (inventory *Inventory) RemoveCredentials() {
+	inventory.Nodes = append([]Inventory(nil), inventory.Nodes...)
+	for i := range inventory.Nodes {
+		inventory.Nodes[i].RemoveCredentials()
+	}
+	inventory.Modules = append([]ModuleConfig(nil), inventory.Modules...)
+	for i := range inventory.Modules {
+		options := make([]ModuleOption, len(inventory.Modules[i].Options))
+		for j, option := range inventory.Modules[i].Options {
+			if IsCredentialName(option.Key) {
+				option.Value = ""
+			}
+			options[j] = option
+		}
+		inventory.Modules[i].Options = options
+	}
+	inventory.OpenIdClients = append([]OpenIdClient(nil), inventory.OpenIdClients...)
+	inventory.IdentityProviders = append([]OpenIdIdentityProvider(nil), inventory.IdentityProviders...)
+	inventory.Users = append([]User(nil), inventory.Users...)
+	for i := range inventory.OpenIdClients {
+		secrets := make([]ClientSecret, len(inventory.OpenIdClients[i].ClientSecrets))
+		for j, secret := range inventory.OpenIdClients[i].ClientSecrets {
+			secret.Secret = ""
+			secrets[j] = secret
+		}
+		inventory.OpenIdClients[i].ClientSecrets = secrets
+	}
+	for i := range inventory.IdentityProviders {
+		inventory.IdentityProviders[i].TokenIntrospectionClientSecret = ""
+	}
+	for i := range inventory.Users {
+		inventory.Users[i].Password = ""
+	}
+}
+
+func ReadInventoryFile(path string) (Inventory, error) {
+	var inventory Inventory
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return inventory, err
+	}
+	if err := json.Unmarshal(content, &inventory); err != nil {
+		return inventory, fmt.Errorf("%s is not an inventory document: %w", path, err)
+	}
+	if inventory.Version < 1 || inventory.Version > InventoryVersion {
+		return inventory, fmt.Errorf("%s has inventory version %d, this version of the provider reads versions 1 to %d", path, inventory.Version, InventoryVersion)
+	}
+
+	return inventory, nil
+}
+
+func WriteInventoryFile(path string, inventory Inventory) error {
+	content, err := json.MarshalIndent(inventory, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(content, '\n'), 0o600)
+}
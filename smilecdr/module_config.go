@@ -52,6 +52,17 @@ func (moduleConfig *ModuleConfig) LookupOptionOk(key string) (string, bool) {
 	return "", false
 }
 
+// SetOption sets the value of an option, replacing the option of the same key if there is one.
+func (moduleConfig *ModuleConfig) SetOption(key string, value string) {
+	for i, kv := range moduleConfig.Options {
+		if kv.Key == key {
+			moduleConfig.Options[i].Value = value
+			return
+		}
+	}
+	moduleConfig.Options = append(moduleConfig.Options, ModuleOption{Key: key, Value: value})
+}
+
 func (smilecdr *Client) GetModuleConfigs(ctx context.Context) ([]ModuleConfig, error) {
 	var modules []ModuleConfig
 	jsonBody, getErr := smilecdr.Get(ctx, "/module-config")
@@ -135,3 +146,15 @@ func (smilecdr *Client) DeleteModuleConfig(ctx context.Context, nodeId string, m
 	_, err := smilecdr.Delete(ctx, endpoint)
 	return err
 }
+
+func (smilecdr *Client) StopModule(ctx context.Context, nodeId string, moduleId string) error {
+	var endpoint = fmt.Sprintf("/module-config/%s/%s/stop", nodeId, moduleId)
+	_, err := smilecdr.Post(ctx, endpoint, nil)
+	return err
+}
+
+func (smilecdr *Client) StartModule(ctx context.Context, nodeId string, moduleId string) error {
+	var endpoint = fmt.Sprintf("/module-config/%s/%s/start", nodeId, moduleId)
+	_, err := smilecdr.Post(ctx, endpoint, nil)
+	return err
+}
@@ -0,0 +1,50 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package smilecdr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+type Node struct {
+	NodeId  string         `json:"nodeId,omitempty"`
+	Modules []ModuleConfig `json:"modules,omitempty"`
+}
+
+type NodeList struct {
+	Nodes []Node `json:"nodes"`
+}
+
+// GetNodes lists the nodes of the cluster, with the modules configured on each one.
+func (smilecdr *Client) GetNodes(ctx context.Context) ([]Node, error) {
+	var nodes NodeList
+	jsonBody, getErr := smilecdr.Get(ctx, "/cluster-manager/nodes")
+	if getErr != nil {
+		return nodes.Nodes, getErr
+	}
+
+	err := json.Unmarshal(jsonBody, &nodes)
+
+	return nodes.Nodes, err
+}
+
+// GetNodeModuleConfigs lists the modules of a node through the cluster endpoint, falling back to every
+// module known to the server when the endpoint is not available.
+func (smilecdr *Client) GetNodeModuleConfigs(ctx context.Context, nodeId string) ([]ModuleConfig, error) {
+	nodes, err := smilecdr.GetNodes(ctx)
+	if IsNotFound(err) {
+		return smilecdr.GetModuleConfigs(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		if node.NodeId == nodeId {
+			return node.Modules, nil
+		}
+	}
+	return nil, fmt.Errorf("node %s does not exist", nodeId)
+}
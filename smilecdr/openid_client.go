@@ -27,29 +27,29 @@ type OpenIdClient struct {
 	NodeId                      string           `json:"nodeId,omitempty"`
 	ModuleId                    string           `json:"moduleId,omitempty"`
 	ClientId                    string           `json:"clientId,omitempty"`
-	ClientName                  string           `json:"clientName,omitempty"`
-	Enabled                     bool             `json:"enabled,omitempty"`
-	AccessTokenValiditySeconds  int              `json:"accessTokenValiditySeconds,omitempty"`
-	AllowedGrantTypes           []string         `json:"allowedGrantTypes,omitempty"`
-	AlwaysRequireApproval       bool             `json:"alwaysRequireApproval,omitempty"`
-	AttestationAccepted         bool             `json:"attestationAccepted,omitempty"`
-	AutoApproveScopes           []string         `json:"autoApproveScopes,omitempty"`
-	AutoGrantScopes             []string         `json:"autoGrantScopes,omitempty"`
-	CanIntrospectAnyTokens      bool             `json:"canIntrospectAnyTokens,omitempty"`
-	CanIntrospectOwnTokens      bool             `json:"canIntrospectOwnTokens,omitempty"`
-	CanReissueTokens            bool             `json:"canReissueTokens,omitempty"`
-	ClientSecrets               []ClientSecret   `json:"clientSecrets,omitempty"`
-	CreatedByAppSphere          bool             `json:"createdByAppSphere,omitempty"`
-	FixedScope                  bool             `json:"fixedScope,omitempty"`
-	JwksUrl                     string           `json:"jwksUrl,omitempty"`
-	Permissions                 []UserPermission `json:"permissions,omitempty"`
-	PublicJwks                  string           `json:"publicJwks,omitempty"`
-	RefreshTokenValiditySeconds int              `json:"refreshTokenValiditySeconds,omitempty"`
-	RegisteredRedirectUris      []string         `json:"registeredRedirectUris,omitempty"`
-	RememberApprovedScopes      bool             `json:"rememberApprovedScopes,omitempty"`
-	Scopes                      []string         `json:"scopes,omitempty"`
-	SecretClientCanChange       bool             `json:"secretClientCanChange,omitempty"`
-	SecretRequired              bool             `json:"secretRequired,omitempty"`
+	ClientName                  string           `json:"clientName"`
+	Enabled                     *bool            `json:"enabled,omitempty"`
+	AccessTokenValiditySeconds  *int             `json:"accessTokenValiditySeconds,omitempty"`
+	AllowedGrantTypes           []string         `json:"allowedGrantTypes"`
+	AlwaysRequireApproval       *bool            `json:"alwaysRequireApproval,omitempty"`
+	AttestationAccepted         *bool            `json:"attestationAccepted,omitempty"`
+	AutoApproveScopes           []string         `json:"autoApproveScopes"`
+	AutoGrantScopes             []string         `json:"autoGrantScopes"`
+	CanIntrospectAnyTokens      *bool            `json:"canIntrospectAnyTokens,omitempty"`
+	CanIntrospectOwnTokens      *bool            `json:"canIntrospectOwnTokens,omitempty"`
+	CanReissueTokens            *bool            `json:"canReissueTokens,omitempty"`
+	ClientSecrets               []ClientSecret   `json:"clientSecrets"`
+	CreatedByAppSphere          *bool            `json:"createdByAppSphere,omitempty"`
+	FixedScope                  *bool            `json:"fixedScope,omitempty"`
+	JwksUrl                     string           `json:"jwksUrl"`
+	Permissions                 []UserPermission `json:"permissions"`
+	PublicJwks                  string           `json:"publicJwks"`
+	RefreshTokenValiditySeconds *int             `json:"refreshTokenValiditySeconds,omitempty"`
+	RegisteredRedirectUris      []string         `json:"registeredRedirectUris"`
+	RememberApprovedScopes      *bool            `json:"rememberApprovedScopes,omitempty"`
+	Scopes                      []string         `json:"scopes"`
+	SecretClientCanChange       *bool            `json:"secretClientCanChange,omitempty"`
+	SecretRequired              *bool            `json:"secretRequired,omitempty"`
 	ArchivedAt                  string           `json:"archivedAt,omitempty"`
 }
 
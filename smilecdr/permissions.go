@@ -0,0 +1,41 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package smilecdr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+type PermissionDefinition struct {
+	Name             string `json:"name,omitempty"`
+	Description      string `json:"description,omitempty"`
+	ArgumentRequired bool   `json:"argumentRequired,omitempty"`
+}
+
+func (smilecdr *Client) GetPermissions(ctx context.Context) ([]PermissionDefinition, error) {
+	var permissions []PermissionDefinition
+	jsonBody, getErr := smilecdr.Get(ctx, "/user-management/permissions")
+	if getErr != nil {
+		fmt.Println("error during Get in GetPermissions:", getErr)
+		return permissions, getErr
+	}
+
+	err := json.Unmarshal(jsonBody, &permissions)
+	if err != nil {
+		fmt.Println("error parsing Get response JSON:", err)
+	}
+
+	return permissions, err
+}
+
+// CachedPermissions fetches the permission catalog of the connected server once per client,
+// and returns the same result for every later call.
+func (smilecdr *Client) CachedPermissions(ctx context.Context) ([]PermissionDefinition, error) {
+	smilecdr.permissionsOnce.Do(func() {
+		smilecdr.permissions, smilecdr.permissionsErr = smilecdr.GetPermissions(ctx)
+	})
+	return smilecdr.permissions, smilecdr.permissionsErr
+}
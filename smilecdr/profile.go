@@ -0,0 +1,121 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package smilecdr
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DefaultProfileName is the profile used when none is selected, if the profiles file defines it.
+const DefaultProfileName = "default"
+
+// Profile holds the connection settings of one Smile CDR environment, read from a profiles file.
+type Profile struct {
+	Name                  string
+	BaseUrl               string
+	AuthMethod            string
+	Username              string
+	Password              string
+	Token                 string
+	CACertFile            string
+	TLSInsecureSkipVerify bool
+	DefaultNodeId         string
+}
+
+// DefaultProfilesPath returns ~/.smilecdr/config.
+func DefaultProfilesPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".smilecdr", "config")
+}
+
+// ReadProfiles reads a profiles file made of [name] sections of key = value lines, e.g.
+//
+//	[prod]
+//	base_url = https://smilecdr.example.org:9000
+//	username = admin
+//	password = ...
+//
+// Blank lines and lines starting with # or ; are ignored.
+func ReadProfiles(path string) (map[string]Profile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	profiles := map[string]Profile{}
+	var current *Profile
+	lineNumber := 0
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			profile := profiles[name]
+			profile.Name = name
+			profiles[name] = profile
+			current = &profile
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected key = value", path, lineNumber)
+		}
+		if current == nil {
+			return nil, fmt.Errorf("%s:%d: %s is not in a [profile] section", path, lineNumber, strings.TrimSpace(key))
+		}
+		if err := current.set(strings.TrimSpace(key), strings.Trim(strings.TrimSpace(value), `"`)); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+		profiles[current.Name] = *current
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return profiles, nil
+}
+
+func (profile *Profile) set(key string, value string) error {
+	switch key {
+	case "base_url":
+		profile.BaseUrl = value
+	case "auth_method":
+		profile.AuthMethod = value
+	case "username":
+		profile.Username = value
+	case "password":
+		profile.Password = value
+	case "token":
+		profile.Token = value
+	case "ca_cert_file":
+		profile.CACertFile = value
+	case "tls_insecure_skip_verify":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("tls_insecure_skip_verify must be true or false")
+		}
+		profile.TLSInsecureSkipVerify = b
+	case "default_node_id":
+		profile.DefaultNodeId = value
+	default:
+		return fmt.Errorf("unknown setting %s", key)
+	}
+	return nil
+}
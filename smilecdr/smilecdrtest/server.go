@@ -0,0 +1,374 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+// Package smilecdrtest provides an in-memory stand-in for the Smile CDR Admin JSON API,
+// covering the endpoints used by the smilecdr client, for tests that cannot reach a real server.
+//
+// Updates are partial: fields of users and OpenID clients left out of a PUT keep their value, and so do
+// module options left out of a module configuration. This is how the server is assumed to merge
+// updates, not a behaviour checked against a real server, so tests relying on it do not prove that a
+// field left out of a request keeps its value on the server.
+package smilecdrtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/zedwerks/terraform-smilecdr/smilecdr"
+)
+
+// Server holds the objects of the stand-in. Its fields may be seeded before use, and inspected after,
+// while holding no lock: requests are only served between calls to Start and Close.
+type Server struct {
+	// NodeIds lists the nodes of the cluster, along with the nodes of Modules.
+	NodeIds           []string
+	Modules           map[string]smilecdr.ModuleConfig // by "nodeId/moduleId"
+	OpenIdClients     []smilecdr.OpenIdClient
+	IdentityProviders []smilecdr.OpenIdIdentityProvider
+	Users             []smilecdr.User
+
+	// Permissions is the permission catalog of the server; none is served while it is nil.
+	Permissions []smilecdr.PermissionDefinition
+
+	// TwoFactorAuthRequired holds the two-factor authentication requirement of users, by pid.
+	TwoFactorAuthRequired map[int]bool
+	// TwoFactorAuthUnavailable stops serving the two-factor authentication endpoints, as on servers
+	// without two-factor authentication.
+	TwoFactorAuthUnavailable bool
+
+	// Requests lists every request served, as "METHOD /path".
+	Requests []string
+
+	mu      sync.Mutex
+	nextPid int
+	server  *httptest.Server
+}
+
+func NewServer() *Server {
+	return &Server{
+		NodeIds:               []string{"Master"},
+		Modules:               map[string]smilecdr.ModuleConfig{},
+		TwoFactorAuthRequired: map[int]bool{},
+		nextPid:               1000,
+	}
+}
+
+// Start serves the stand-in and returns its base URL.
+func (s *Server) Start() string {
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s.server.URL
+}
+
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+func (s *Server) pid() int {
+	s.nextPid++
+	return s.nextPid
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Requests = append(s.Requests, r.Method+" "+r.URL.Path)
+
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch path[0] {
+	case "cluster-manager":
+		s.serveClusterManager(w, r, path[1:])
+	case "module-config":
+		s.serveModuleConfig(w, r, path[1:])
+	case "openid-connect-clients":
+		s.serveOpenIdClients(w, r, path[1:])
+	case "openid-connect-servers":
+		s.serveIdentityProviders(w, r, path[1:])
+	case "user-management":
+		s.serveUsers(w, r, path[1:])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		http.Error(w, `{"message":"invalid JSON body"}`, http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func (s *Server) serveClusterManager(w http.ResponseWriter, r *http.Request, path []string) {
+	if len(path) != 1 || path[0] != "nodes" || r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+
+	keys := make([]string, 0, len(s.Modules))
+	for key := range s.Modules {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	nodes := map[string]*smilecdr.Node{}
+	for _, nodeId := range s.NodeIds {
+		nodes[nodeId] = &smilecdr.Node{NodeId: nodeId}
+	}
+	for _, key := range keys {
+		nodeId := strings.SplitN(key, "/", 2)[0]
+		if nodes[nodeId] == nil {
+			nodes[nodeId] = &smilecdr.Node{NodeId: nodeId}
+		}
+		nodes[nodeId].Modules = append(nodes[nodeId].Modules, s.Modules[key])
+	}
+
+	list := smilecdr.NodeList{Nodes: []smilecdr.Node{}}
+	for _, node := range nodes {
+		list.Nodes = append(list.Nodes, *node)
+	}
+	sort.Slice(list.Nodes, func(i, j int) bool { return list.Nodes[i].NodeId < list.Nodes[j].NodeId })
+	writeJSON(w, list)
+}
+
+func (s *Server) serveModuleConfig(w http.ResponseWriter, r *http.Request, path []string) {
+	if len(path) == 0 && r.Method == http.MethodGet {
+		keys := make([]string, 0, len(s.Modules))
+		for key := range s.Modules {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		modules := []smilecdr.ModuleConfig{}
+		for _, key := range keys {
+			modules = append(modules, s.Modules[key])
+		}
+		writeJSON(w, modules)
+		return
+	}
+	if len(path) < 2 {
+		http.NotFound(w, r)
+		return
+	}
+
+	key := path[0] + "/" + path[1]
+	module, exists := s.Modules[key]
+	action := ""
+	if len(path) > 2 {
+		action = path[2]
+	}
+
+	switch {
+	case r.Method == http.MethodGet && action == "" && exists:
+		writeJSON(w, module)
+	case r.Method == http.MethodPost && action == "create" && !exists:
+		if readJSON(w, r, &module) {
+			module.ModuleId = path[1]
+			s.Modules[key] = module
+			writeJSON(w, module)
+		}
+	case r.Method == http.MethodPut && action == "set" && exists:
+		// Options left out of the body keep their value, and an option sent without a value is cleared.
+		stored := module.Options
+		module.Options = nil
+		if readJSON(w, r, &module) {
+			for _, option := range stored {
+				if _, ok := module.LookupOptionOk(option.Key); !ok {
+					module.Options = append(module.Options, option)
+				}
+			}
+			s.Modules[key] = module
+			writeJSON(w, module)
+		}
+	case r.Method == http.MethodPost && (action == "stop" || action == "start") && exists:
+		writeJSON(w, struct{}{})
+	case r.Method == http.MethodDelete && action == "archive" && exists:
+		delete(s.Modules, key)
+		writeJSON(w, module)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) serveOpenIdClients(w http.ResponseWriter, r *http.Request, path []string) {
+	if len(path) == 0 && r.Method == http.MethodGet {
+		writeJSON(w, append([]smilecdr.OpenIdClient{}, s.OpenIdClients...))
+		return
+	}
+	if len(path) == 2 && r.Method == http.MethodPost {
+		var client smilecdr.OpenIdClient
+		if readJSON(w, r, &client) {
+			client.Pid = s.pid()
+			client.NodeId, client.ModuleId = path[0], path[1]
+			s.OpenIdClients = append(s.OpenIdClients, client)
+			writeJSON(w, client)
+		}
+		return
+	}
+	if len(path) != 3 {
+		http.NotFound(w, r)
+		return
+	}
+
+	for i, client := range s.OpenIdClients {
+		if client.NodeId != path[0] || client.ModuleId != path[1] || client.ClientId != path[2] {
+			continue
+		}
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, client)
+		case http.MethodPut:
+			// As the server is assumed to, fields left out of the request keep their value.
+			if readJSON(w, r, &s.OpenIdClients[i]) {
+				writeJSON(w, s.OpenIdClients[i])
+			}
+		case http.MethodDelete:
+			s.OpenIdClients = append(s.OpenIdClients[:i], s.OpenIdClients[i+1:]...)
+			writeJSON(w, client)
+		}
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func (s *Server) serveIdentityProviders(w http.ResponseWriter, r *http.Request, path []string) {
+	if len(path) == 0 && r.Method == http.MethodGet {
+		writeJSON(w, append([]smilecdr.OpenIdIdentityProvider{}, s.IdentityProviders...))
+		return
+	}
+	if len(path) < 2 {
+		http.NotFound(w, r)
+		return
+	}
+
+	if len(path) == 2 {
+		switch r.Method {
+		case http.MethodPost:
+			var provider smilecdr.OpenIdIdentityProvider
+			if readJSON(w, r, &provider) {
+				provider.Pid = s.pid()
+				provider.NodeId, provider.ModuleId = path[0], path[1]
+				s.IdentityProviders = append(s.IdentityProviders, provider)
+				writeJSON(w, provider)
+			}
+			return
+		case http.MethodGet:
+			issuer := r.URL.Query().Get("issuer_url")
+			for _, provider := range s.IdentityProviders {
+				if provider.NodeId == path[0] && provider.ModuleId == path[1] && provider.Issuer == issuer {
+					writeJSON(w, provider)
+					return
+				}
+			}
+		}
+		http.NotFound(w, r)
+		return
+	}
+
+	pid, _ := strconv.Atoi(path[2])
+	for i, provider := range s.IdentityProviders {
+		if provider.NodeId != path[0] || provider.ModuleId != path[1] || provider.Pid != pid {
+			continue
+		}
+		switch r.Method {
+		case http.MethodPut:
+			if readJSON(w, r, &s.IdentityProviders[i]) {
+				writeJSON(w, s.IdentityProviders[i])
+			}
+		case http.MethodDelete:
+			s.IdentityProviders = append(s.IdentityProviders[:i], s.IdentityProviders[i+1:]...)
+			writeJSON(w, provider)
+		}
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func (s *Server) serveUsers(w http.ResponseWriter, r *http.Request, path []string) {
+	if len(path) == 1 && path[0] == "permissions" && r.Method == http.MethodGet && s.Permissions != nil {
+		writeJSON(w, s.Permissions)
+		return
+	}
+	if len(path) == 2 {
+		switch r.Method {
+		case http.MethodGet:
+			search := strings.ToLower(r.URL.Query().Get("searchTerm"))
+			users := []smilecdr.User{}
+			for _, user := range s.Users {
+				if user.NodeId == path[0] && user.ModuleId == path[1] && strings.Contains(strings.ToLower(user.Username), search) {
+					user.Password = ""
+					users = append(users, user)
+				}
+			}
+			writeJSON(w, smilecdr.UserList{Users: users})
+		case http.MethodPost:
+			var user smilecdr.User
+			if readJSON(w, r, &user) {
+				user.Pid = s.pid()
+				user.NodeId, user.ModuleId = path[0], path[1]
+				s.Users = append(s.Users, user)
+				user.Password = ""
+				writeJSON(w, user)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+		return
+	}
+	if len(path) < 3 {
+		http.NotFound(w, r)
+		return
+	}
+
+	pid, _ := strconv.Atoi(path[2])
+	for i, user := range s.Users {
+		if user.NodeId != path[0] || user.ModuleId != path[1] || user.Pid != pid {
+			continue
+		}
+		action := strings.Join(path[3:], "/")
+		if strings.HasPrefix(action, "two-factor-auth") && s.TwoFactorAuthUnavailable {
+			http.NotFound(w, r)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && action == "":
+			user.Password = ""
+			writeJSON(w, user)
+		case r.Method == http.MethodPut && action == "":
+			// As the server is assumed to, fields left out of the request keep their value.
+			updated := user
+			if readJSON(w, r, &updated) {
+				s.Users[i] = updated
+				updated.Password = ""
+				writeJSON(w, updated)
+			}
+		case r.Method == http.MethodPost && action == "unlock":
+			s.Users[i].AccountLocked = smilecdr.Bool(false)
+			s.Users[i].FailedLoginCount = 0
+			writeJSON(w, struct{}{})
+		case r.Method == http.MethodGet && action == "two-factor-auth":
+			writeJSON(w, smilecdr.TwoFactorAuthStatus{Required: s.TwoFactorAuthRequired[pid]})
+		case r.Method == http.MethodPut && action == "two-factor-auth/required":
+			var required smilecdr.TwoFactorAuthStatus
+			if readJSON(w, r, &required) {
+				s.TwoFactorAuthRequired[pid] = required.Required
+				writeJSON(w, required)
+			}
+		case r.Method == http.MethodPost && action == "two-factor-auth/reset":
+			writeJSON(w, struct{}{})
+		default:
+			http.NotFound(w, r)
+		}
+		return
+	}
+	http.NotFound(w, r)
+}
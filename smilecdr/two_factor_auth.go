@@ -0,0 +1,60 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package smilecdr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+type TwoFactorAuthStatus struct {
+	Required   bool   `json:"required,omitempty"`
+	Enrolled   bool   `json:"enrolled,omitempty"`
+	Status     string `json:"status,omitempty"`
+	EnrolledAt string `json:"enrolledAt,omitempty"`
+}
+
+type twoFactorAuthRequired struct {
+	Required bool `json:"required"`
+}
+
+func (smilecdr *Client) GetUserTwoFactorAuth(ctx context.Context, nodeId string, moduleId string, pid int) (TwoFactorAuthStatus, error) {
+	var status TwoFactorAuthStatus
+	var endpoint = fmt.Sprintf("/user-management/%s/%s/%d/two-factor-auth", nodeId, moduleId, pid)
+	jsonBody, err := smilecdr.Get(ctx, endpoint)
+	if err != nil {
+		fmt.Println("error during Get in GetUserTwoFactorAuth:", err)
+		return status, err
+	}
+
+	err = json.Unmarshal(jsonBody, &status)
+	if err != nil {
+		fmt.Println("error parsing Get response JSON:", err)
+	}
+	return status, err
+}
+
+// SetUserTwoFactorAuthRequired requires, or stops requiring, the user to enrol a TOTP key before logging in.
+func (smilecdr *Client) SetUserTwoFactorAuthRequired(ctx context.Context, nodeId string, moduleId string, pid int, required bool) error {
+	var endpoint = fmt.Sprintf("/user-management/%s/%s/%d/two-factor-auth/required", nodeId, moduleId, pid)
+	jsonBody, _ := json.Marshal(twoFactorAuthRequired{Required: required})
+
+	_, err := smilecdr.Put(ctx, endpoint, jsonBody)
+	if err != nil {
+		fmt.Println("error during Put in SetUserTwoFactorAuthRequired:", err)
+	}
+	return err
+}
+
+// ResetUserTwoFactorAuthKey discards the enrolled TOTP key of the user, who must enrol again on the next login.
+func (smilecdr *Client) ResetUserTwoFactorAuthKey(ctx context.Context, nodeId string, moduleId string, pid int) error {
+	var endpoint = fmt.Sprintf("/user-management/%s/%s/%d/two-factor-auth/reset", nodeId, moduleId, pid)
+
+	_, err := smilecdr.Post(ctx, endpoint, []byte("{}"))
+	if err != nil {
+		fmt.Println("error during Post in ResetUserTwoFactorAuthKey:", err)
+	}
+	return err
+}
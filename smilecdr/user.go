@@ -7,6 +7,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"strings"
 )
 
 type UserAuthorities struct {
@@ -20,19 +22,21 @@ type User struct {
 	ModuleId            string            `json:"moduleId,omitempty"`
 	Username            string            `json:"username,omitempty"`
 	Password            string            `json:"password,omitempty"`
-	FamilyName          string            `json:"familyName,omitempty"`
-	GivenName           string            `json:"givenName,omitempty"`
-	AccountLocked       bool              `json:"accountLocked,omitempty"`
-	SystemUser          bool              `json:"systemUser,omitempty"`
-	AccountDisabled     bool              `json:"accountDisabled,omitempty"`
-	External            bool              `json:"external,omitempty"`
-	ServiceAccount      bool              `json:"serviceAccount,omitempty"`
+	FamilyName          string            `json:"familyName"`
+	GivenName           string            `json:"givenName"`
+	AccountLocked       *bool             `json:"accountLocked,omitempty"`
+	SystemUser          *bool             `json:"systemUser,omitempty"`
+	AccountDisabled     *bool             `json:"accountDisabled,omitempty"`
+	External            *bool             `json:"external,omitempty"`
+	ServiceAccount      *bool             `json:"serviceAccount,omitempty"`
 	TwoFactorAuthStatus string            `json:"twoFactorAuthStatus,omitempty"`
-	Authorities         []UserAuthorities `json:"authorities,omitempty"`
+	Authorities         []UserAuthorities `json:"authorities"`
 
 	// These fields are Computed
-	LastConnected string `json:"lastConnected,omitempty"`
-	LastActive    string `json:"lastActive,omitempty"`
+	LastConnected    string `json:"lastConnected,omitempty"`
+	LastActive       string `json:"lastActive,omitempty"`
+	FailedLoginCount int    `json:"failedLoginCount,omitempty"`
+	LockedAt         string `json:"lockedAt,omitempty"`
 }
 
 func (smilecdr *Client) GetUser(ctx context.Context, nodeId string, moduleId string, pid int) (User, error) {
@@ -101,6 +105,17 @@ func (smilecdr *Client) PutUser(ctx context.Context, user User) (User, error) {
 	return updatedUser, err
 }
 
+// UnlockUser clears the lock and the failed login count of the user, without changing anything else.
+func (smilecdr *Client) UnlockUser(ctx context.Context, nodeId string, moduleId string, pid int) error {
+	var endpoint = fmt.Sprintf("/user-management/%s/%s/%d/unlock", nodeId, moduleId, pid)
+
+	_, err := smilecdr.Post(ctx, endpoint, []byte("{}"))
+	if err != nil {
+		fmt.Println("error during Post in UnlockUser:", err)
+	}
+	return err
+}
+
 func (smilecdr *Client) DeleteUser(ctx context.Context, nodeId string, moduleId string, pid int) error {
 	var endpoint = fmt.Sprintf("/user-management/%s/%s/%d", nodeId, moduleId, pid)
 	_, err := smilecdr.Delete(ctx, endpoint)
@@ -109,3 +124,40 @@ func (smilecdr *Client) DeleteUser(ctx context.Context, nodeId string, moduleId
 	}
 	return err
 }
+
+type UserList struct {
+	Users []User `json:"users,omitempty"`
+}
+
+func (smilecdr *Client) GetUsers(ctx context.Context, nodeId string, moduleId string, searchTerm string) ([]User, error) {
+	var users UserList
+	var endpoint = fmt.Sprintf("/user-management/%s/%s?searchTerm=%s", nodeId, moduleId, url.QueryEscape(searchTerm))
+	jsonBody, err := smilecdr.Get(ctx, endpoint)
+	if err != nil {
+		fmt.Println("error during Get in GetUsers:", err)
+		return users.Users, err
+	}
+
+	if jsonBody != nil {
+		err = json.Unmarshal(jsonBody, &users)
+		if err != nil {
+			fmt.Println("error parsing Get response JSON:", err)
+		}
+	}
+	return users.Users, err
+}
+
+// GetUserByUsername searches the user list of a module for an exact match of the username.
+func (smilecdr *Client) GetUserByUsername(ctx context.Context, nodeId string, moduleId string, username string) (User, error) {
+	users, err := smilecdr.GetUsers(ctx, nodeId, moduleId, username)
+	if err != nil {
+		return User{}, err
+	}
+
+	for _, user := range users {
+		if strings.EqualFold(user.Username, username) {
+			return user, nil
+		}
+	}
+	return User{}, fmt.Errorf("user '%s' not found in module %s/%s", username, nodeId, moduleId)
+}
@@ -0,0 +1,30 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package smilecdr
+
+// The bool and int fields of the Admin API models are pointers, so that an explicit false or 0 is sent
+// to the server, while a nil field is left out of the request.
+
+// Bool returns a pointer to v.
+func Bool(v bool) *bool {
+	return &v
+}
+
+// Int returns a pointer to v.
+func Int(v int) *int {
+	return &v
+}
+
+// BoolValue returns the value of p, or false when p is nil.
+func BoolValue(p *bool) bool {
+	return p != nil && *p
+}
+
+// IntValue returns the value of p, or 0 when p is nil.
+func IntValue(p *int) int {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
@@ -0,0 +1,72 @@
+// Copyright (c) Zed Werks Inc.
+// SPDX-License-Identifier: APACHE-2.0
+
+package smilecdr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a Smile CDR release version, e.g. 2024.05.R01. Releases are compared by year and month.
+type Version struct {
+	Year    int
+	Month   int
+	Release string
+}
+
+type ServerInfo struct {
+	Version string `json:"version"`
+}
+
+// ParseVersion parses a version of the form YYYY.MM or YYYY.MM.RELEASE.
+func ParseVersion(version string) (Version, error) {
+	parts := strings.SplitN(strings.TrimSpace(version), ".", 3)
+	if len(parts) < 2 {
+		return Version{}, fmt.Errorf("invalid Smile CDR version %q, expected YYYY.MM or YYYY.MM.RELEASE", version)
+	}
+	year, yearErr := strconv.Atoi(parts[0])
+	month, monthErr := strconv.Atoi(parts[1])
+	if yearErr != nil || monthErr != nil || month < 1 || month > 12 {
+		return Version{}, fmt.Errorf("invalid Smile CDR version %q, expected YYYY.MM or YYYY.MM.RELEASE", version)
+	}
+
+	v := Version{Year: year, Month: month}
+	if len(parts) == 3 {
+		v.Release = parts[2]
+	}
+	return v, nil
+}
+
+// AtLeast reports whether the version is the release of minimum or a later one.
+func (v Version) AtLeast(minimum Version) bool {
+	if v.Year != minimum.Year {
+		return v.Year > minimum.Year
+	}
+	return v.Month >= minimum.Month
+}
+
+func (v Version) String() string {
+	if v.Release == "" {
+		return fmt.Sprintf("%d.%02d", v.Year, v.Month)
+	}
+	return fmt.Sprintf("%d.%02d.%s", v.Year, v.Month, v.Release)
+}
+
+// GetServerVersion reads the version of the server from its server information.
+func (smilecdr *Client) GetServerVersion(ctx context.Context) (Version, error) {
+	var info ServerInfo
+	jsonBody, getErr := smilecdr.Get(ctx, "/server-info")
+	if getErr != nil {
+		return Version{}, getErr
+	}
+
+	if err := json.Unmarshal(jsonBody, &info); err != nil {
+		return Version{}, err
+	}
+
+	return ParseVersion(info.Version)
+}